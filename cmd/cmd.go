@@ -35,6 +35,12 @@ func FlagBuildpack(v *string) {
 	flag.StringVar(v, "buildpack", d, "buildpack directory for this buildpack")
 }
 
+// FlagPlan is the build plan file the buildpack contributes entries to. The
+// plan of the buildpacks that ran before is read from stdin when it is set.
+func FlagPlan(v *string) {
+	flag.StringVar(v, "plan", "", "build plan file to contribute to")
+}
+
 const (
 	CodeFailed      = 1
 	CodeInvalidArgs = iota + 2
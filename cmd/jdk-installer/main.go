@@ -4,6 +4,7 @@ import (
 	"flag"
 	"os"
 
+	"github.com/buildpack/libbuildpack/buildplan"
 	"github.com/buildpack/libbuildpack/layers"
 	"github.com/buildpack/libbuildpack/logger"
 	"github.com/buildpack/libbuildpack/platform"
@@ -15,11 +16,13 @@ var (
 	platformRoot  string
 	layersRoot    string
 	buildpackRoot string
+	planPath      string
 )
 
 func init() {
 	cmd.FlagPlatform(&platformRoot)
 	cmd.FlagLayers(&layersRoot)
+	cmd.FlagPlan(&planPath)
 
 	// TODO shouldn't we be able to find this from the binary?
 	cmd.FlagBuildpack(&buildpackRoot)
@@ -31,10 +34,10 @@ func main() {
 		cmd.Exit(cmd.FailCode(cmd.CodeInvalidArgs, "parse arguments"))
 	}
 
-	cmd.Exit(runGoals(platformRoot, layersRoot, buildpackRoot))
+	cmd.Exit(runGoals(platformRoot, layersRoot, buildpackRoot, planPath))
 }
 
-func runGoals(platformRoot, layersRoot, buildpackRoot string) error {
+func runGoals(platformRoot, layersRoot, buildpackRoot, planPath string) error {
 	log := logger.DefaultLogger()
 
 	bpPlatform, err := platform.DefaultPlatform(platformRoot, log)
@@ -54,11 +57,19 @@ func runGoals(platformRoot, layersRoot, buildpackRoot string) error {
 		return err
 	}
 
+	plan := buildplan.BuildPlan{}
+	if planPath != "" {
+		if err := plan.Init(); err != nil {
+			return err
+		}
+	}
+
 	jdkInstaller := jdk.Installer{
 		In:           []byte{},
 		Out:          os.Stdout,
 		Err:          os.Stderr,
 		BuildpackDir: buildpackRoot,
+		Plan:         plan,
 	}
 	jdkInstall, err := jdkInstaller.Install(appDir, layersDir)
 	if err != nil {
@@ -66,5 +77,14 @@ func runGoals(platformRoot, layersRoot, buildpackRoot string) error {
 	}
 	println("Java", jdkInstall.Version.Tag, "installed")
 
+	if planPath != "" {
+		// later buildpacks build with the JDK, which is the build JDK when the app has one
+		buildJdk, err := jdk.BuildJdk(layersDir)
+		if err != nil {
+			return err
+		}
+		return jdk.WritePlan(planPath, buildJdk)
+	}
+
 	return nil
 }
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/buildpack/libbuildpack/logger"
+	"github.com/buildpack/libbuildpack/platform"
+	"github.com/heroku/java-buildpack/cmd"
+	"github.com/heroku/java-buildpack/jdk"
+)
+
+var (
+	platformRoot  string
+	layersRoot    string
+	buildpackRoot string
+)
+
+func init() {
+	cmd.FlagPlatform(&platformRoot)
+	cmd.FlagLayers(&layersRoot)
+	cmd.FlagBuildpack(&buildpackRoot)
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() != 0 {
+		cmd.Exit(cmd.FailCode(cmd.CodeInvalidArgs, "parse arguments"))
+	}
+
+	cmd.Exit(link(platformRoot, layersRoot, buildpackRoot))
+}
+
+func link(platformRoot, layersRoot, buildpackRoot string) error {
+	log := logger.DefaultLogger()
+
+	bpPlatform, err := platform.DefaultPlatform(platformRoot, log)
+	if err != nil {
+		return err
+	}
+
+	err = bpPlatform.EnvironmentVariables.SetAll()
+	if err != nil {
+		return err
+	}
+
+	layersDir := layers.NewLayers(layersRoot, log)
+
+	appDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	linker := jdk.Linker{
+		In:           []byte{},
+		Out:          os.Stdout,
+		Err:          os.Stderr,
+		BuildpackDir: buildpackRoot,
+	}
+	if _, _, err := linker.Link(appDir, layersDir); err != nil {
+		return err
+	}
+
+	return nil
+}
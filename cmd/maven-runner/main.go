@@ -8,6 +8,7 @@ import (
 	"github.com/buildpack/libbuildpack/logger"
 	"github.com/buildpack/libbuildpack/platform"
 	"github.com/heroku/java-buildpack/cmd"
+	"github.com/heroku/java-buildpack/jdk"
 	"github.com/heroku/java-buildpack/maven"
 )
 
@@ -55,10 +56,16 @@ func runGoals(goals, options, platformRoot, layersRoot string) error {
 		return err
 	}
 
+	buildJdk, err := jdk.BuildJdk(layersDir)
+	if err != nil {
+		return err
+	}
+
 	runner := maven.Runner{
-		In:  []byte{},
-		Out: os.Stdout,
-		Err: os.Stderr,
+		In:       []byte{},
+		Out:      os.Stdout,
+		Err:      os.Stderr,
+		JavaHome: buildJdk.Home,
 	}
 
 	if err = runner.Run(appDir, goals, []string{options}, layersDir); err != nil {
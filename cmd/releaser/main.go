@@ -8,6 +8,9 @@ import (
 	"github.com/buildpack/libbuildpack/layers"
 	"github.com/buildpack/libbuildpack/logger"
 	"github.com/heroku/java-buildpack/cmd"
+	"github.com/heroku/java-buildpack/jdk"
+	"github.com/heroku/java-buildpack/maven"
+	"github.com/heroku/java-buildpack/pom"
 	"github.com/heroku/java-buildpack/procfile"
 	"github.com/heroku/java-buildpack/util"
 )
@@ -39,29 +42,46 @@ func writeLaunchMetadata(launchDir string) error {
 
 	processes, err := procfile.Parse(filepath.Join(appDir, "Procfile"))
 	if err != nil {
-		log.Debug(err.Error())
-	} else {
-		logProcessTypes(processes, log)
-		return writeMetadata(launchDir, processes, log)
+		log.Debug("%s", err)
+		processes, err = findExecutableArchive(appDir)
 	}
 
-	processes, err = util.FindExecutableJar(appDir)
 	if err != nil {
-		log.Debug(err.Error())
+		log.Debug("%s", err)
+		log.Info("No process types detected")
+		processes = nil
 	} else {
 		logProcessTypes(processes, log)
-		return writeMetadata(launchDir, processes, log)
 	}
 
-	log.Info("No process types detected")
-	return nil
+	return writeMetadata(launchDir, processes, log)
+}
+
+// findExecutableArchive finds the executable jar, or the executable war of a
+// Spring Boot app that is packaged as a war.
+func findExecutableArchive(appDir string) (layers.Processes, error) {
+	if project, err := pom.Read(appDir); err == nil && project.IsSpringBoot() && project.IsWar() {
+		return util.FindExecutableWar(appDir)
+	}
+	return util.FindExecutableJar(appDir)
 }
 
 func writeMetadata(layersRoot string, processes layers.Processes, log logger.Logger) error {
 	layersDir := layers.NewLayers(layersRoot, log)
 
-	return layersDir.WriteMetadata(layers.Metadata{
+	jdkBom, err := jdk.BomEntries(layersDir)
+	if err != nil {
+		return err
+	}
+
+	mavenBom, err := maven.BomEntries(layersDir)
+	if err != nil {
+		return err
+	}
+
+	return util.WriteLaunchMetadata(layersDir, util.LaunchMetadata{
 		Processes: processes,
+		Bom:       append(jdkBom, mavenBom...),
 	})
 }
 
@@ -1,18 +1,37 @@
 module github.com/heroku/java-buildpack
 
+go 1.27.1
+
 require (
-	bou.ke/monkey v1.0.1 // indirect
-	github.com/bouk/monkey v1.0.1 // indirect
+	github.com/BurntSushi/toml v0.3.1
 	github.com/buildpack/libbuildpack v1.6.0
-	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/fatih/color v1.7.0
 	github.com/google/go-cmp v0.2.0
+	github.com/sclevine/spec v1.2.0
+	gopkg.in/yaml.v2 v2.2.2
+)
+
+require (
+	bou.ke/monkey v1.0.1 // indirect
+	github.com/bouk/monkey v1.0.1 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/fsnotify/fsnotify v1.4.7 // indirect
+	github.com/golang/protobuf v1.2.0 // indirect
+	github.com/hpcloud/tail v1.0.0 // indirect
+	github.com/kr/pretty v0.1.0 // indirect
 	github.com/kr/pty v1.1.3 // indirect
+	github.com/kr/text v0.1.0 // indirect
 	github.com/mattn/go-colorable v0.0.9 // indirect
 	github.com/mattn/go-isatty v0.0.4 // indirect
+	github.com/onsi/ginkgo v1.7.0 // indirect
+	github.com/onsi/gomega v1.4.3 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	github.com/sclevine/spec v1.2.0
 	github.com/stretchr/testify v1.2.2 // indirect
+	golang.org/x/net v0.0.0-20181207154023-610586996380 // indirect
+	golang.org/x/sync v0.0.0-20181108010431-42b317875d0f // indirect
 	golang.org/x/sys v0.0.0-20181212120007-b05ddf57801d // indirect
-	gopkg.in/yaml.v2 v2.2.2
+	golang.org/x/text v0.3.0 // indirect
+	gopkg.in/check.v1 v1.0.0-20180628173108-788fd7840127 // indirect
+	gopkg.in/fsnotify.v1 v1.4.7 // indirect
+	gopkg.in/tomb.v1 v1.0.0-20141024135613-dd632973f1e7 // indirect
 )
@@ -0,0 +1,64 @@
+package jdk
+
+import (
+	"os"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/heroku/java-buildpack/util"
+)
+
+// BomEntries lists the JDKs and runtimes installed in the layers for the
+// image's bill of materials.
+func BomEntries(layersDir layers.Layers) ([]util.BomEntry, error) {
+	var entries []util.BomEntry
+	for _, name := range []string{"jdk", BuildJdkLayerName, JreLayerName} {
+		layer := layersDir.Layer(name)
+
+		var installed Jdk
+		if err := layer.ReadMetadata(&installed); err != nil {
+			return nil, err
+		} else if installed.Home == "" {
+			continue
+		}
+
+		launch, err := isLaunchLayer(layer)
+		if err != nil {
+			return nil, err
+		}
+
+		entryName := "jdk"
+		if name == JreLayerName {
+			entryName = "jre"
+		}
+		entries = append(entries, installed.BomEntry(entryName, name, launch))
+	}
+	return entries, nil
+}
+
+func (jdk Jdk) BomEntry(name, layer string, launch bool) util.BomEntry {
+	return util.BomEntry{
+		Name:    name,
+		Version: jdk.Version.Tag,
+		Metadata: util.BomMetadata{
+			Vendor:      normalizeVendor(jdk.Version.Vendor),
+			Url:         jdk.Url,
+			Sha256:      jdk.Sha256,
+			Stack:       jdk.Stack,
+			InstalledAt: jdk.InstalledAt,
+			Layer:       layer,
+			Launch:      launch,
+		},
+	}
+}
+
+func isLaunchLayer(layer layers.Layer) (bool, error) {
+	flags := struct {
+		Launch bool `toml:"launch"`
+	}{}
+
+	if _, err := toml.DecodeFile(layer.Metadata, &flags); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	return flags.Launch, nil
+}
@@ -0,0 +1,88 @@
+package jdk_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/heroku/java-buildpack/jdk"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestBom(t *testing.T) {
+	spec.Run(t, "Bom", testBom, spec.Report(report.Terminal{}))
+}
+
+func testBom(t *testing.T, when spec.G, it spec.S) {
+	var (
+		installer *jdk.Installer
+		layersDir layers.Layers
+		server    *httptest.Server
+		handler   http.HandlerFunc
+	)
+
+	it.Before(func() {
+		handler = serveTarball(tarball(
+			tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"},
+		))
+		server = startMirror(&handler)
+		installer = newInstaller(ioutil.Discard)
+		layersDir = tempLayers(t)
+	})
+
+	it.After(func() {
+		stopMirror(server)
+		os.RemoveAll(layersDir.Root)
+	})
+
+	when("#BomEntries", func() {
+		it("should list the installed JDK and its runtime", func() {
+			installed, err := installer.Install(fixture("app_with_jdk_11"), layersDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			entries, err := jdk.BomEntries(layersDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(entries) != 2 {
+				t.Fatalf(`BOM entries did not match: got %+v`, entries)
+			}
+
+			jdkEntry, jreEntry := entries[0], entries[1]
+			if jdkEntry.Name != "jdk" || jdkEntry.Version != installed.Version.Tag || jdkEntry.Metadata.Launch || jdkEntry.Metadata.Layer != "jdk" {
+				t.Fatalf(`JDK BOM entry did not match: got %+v`, jdkEntry)
+			}
+
+			if jdkEntry.Metadata.Vendor != "openjdk" || jdkEntry.Metadata.Url != installed.Url || jdkEntry.Metadata.Sha256 == "" || jdkEntry.Metadata.Stack != "heroku-18" || jdkEntry.Metadata.InstalledAt.IsZero() {
+				t.Fatalf(`JDK BOM metadata did not match: got %+v`, jdkEntry.Metadata)
+			}
+
+			if jreEntry.Name != "jre" || !jreEntry.Metadata.Launch || jreEntry.Metadata.Sha256 != jdkEntry.Metadata.Sha256 {
+				t.Fatalf(`JRE BOM entry did not match: got %+v`, jreEntry)
+			}
+		})
+
+		it("should keep the install time of a cached JDK", func() {
+			first, err := installer.Install(fixture("app_with_jdk_11"), layersDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			second, err := installer.Install(fixture("app_with_jdk_11"), layersDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !second.InstalledAt.Equal(first.InstalledAt) {
+				t.Fatalf(`install time did not match: got %s, want %s`, second.InstalledAt, first.InstalledAt)
+			}
+		})
+	})
+}
@@ -0,0 +1,53 @@
+package jdk
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/heroku/java-buildpack/util"
+)
+
+const (
+	// BuildJdkLayerName is the layer of a JDK the app is compiled with that
+	// differs from the one it runs on
+	BuildJdkLayerName = "build-jdk"
+	// BuildVersionProperty sets the build JDK in system.properties
+	BuildVersionProperty = "java.build.version"
+)
+
+// detectBuildVersion finds the JDK the app asks to be compiled with. It is the
+// zero Version when the app builds with its runtime JDK.
+func (i *Installer) detectBuildVersion(appDir string, runtime Version) (Version, error) {
+	sysProps, err := util.ReadPropertiesFile(filepath.Join(appDir, "system.properties"))
+	if err != nil {
+		return Version{}, nil
+	}
+
+	version, ok := sysProps[BuildVersionProperty]
+	if !ok || version == "" {
+		return Version{}, nil
+	}
+
+	v, err := i.Catalog.ParseVersionString(version)
+	if err != nil || v == runtime {
+		return Version{}, err
+	}
+
+	fmt.Fprintf(i.Out, "Building with JDK %s from %s in system.properties\n", version, BuildVersionProperty)
+	return v, nil
+}
+
+// BuildJdk is the installed JDK that builds the app: the build JDK when the
+// app has one, or else its runtime JDK.
+func BuildJdk(layersDir layers.Layers) (Jdk, error) {
+	for _, name := range []string{BuildJdkLayerName, "jdk"} {
+		var jdk Jdk
+		if err := layersDir.Layer(name).ReadMetadata(&jdk); err != nil {
+			return Jdk{}, err
+		} else if jdk.Home != "" {
+			return jdk, nil
+		}
+	}
+	return Jdk{}, nil
+}
@@ -0,0 +1,136 @@
+package jdk_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/heroku/java-buildpack/jdk"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestBuildJdk(t *testing.T) {
+	spec.Run(t, "BuildJdk", testBuildJdk, spec.Report(report.Terminal{}))
+}
+
+func testBuildJdk(t *testing.T, when spec.G, it spec.S) {
+	var (
+		installer *jdk.Installer
+		layersDir layers.Layers
+		server    *httptest.Server
+		handler   http.HandlerFunc
+		appDir    string
+	)
+
+	it.Before(func() {
+		handler = serveTarball(tarball(
+			tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"},
+			tarEntry{Name: "bin/javac", Mode: 0755, Body: "#!/bin/sh"},
+		))
+		server = startMirror(&handler)
+		installer = newInstaller(ioutil.Discard)
+		layersDir = tempLayers(t)
+
+		var err error
+		appDir, err = ioutil.TempDir("", "app")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	it.After(func() {
+		stopMirror(server)
+		os.RemoveAll(layersDir.Root)
+		os.RemoveAll(appDir)
+	})
+
+	systemProperties := func(props string) {
+		if err := ioutil.WriteFile(filepath.Join(appDir, "system.properties"), []byte(props), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	layerToml := func(name string) string {
+		toml, err := ioutil.ReadFile(layersDir.Layer(name).Metadata)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(toml)
+	}
+
+	when("#Install", func() {
+		it("should install a separate build JDK", func() {
+			systemProperties("java.runtime.version=1.8\njava.build.version=11")
+
+			installed, err := installer.Install(appDir, layersDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if installed.Version.Major != 8 || installer.BuildVersion.Major != 11 {
+				t.Fatalf(`JDK versions did not match: got runtime %+v, build %+v`, installed.Version, installer.BuildVersion)
+			}
+
+			if jdkToml := layerToml("jdk"); !strings.Contains(jdkToml, "build = false") || !strings.Contains(jdkToml, "launch = false") {
+				t.Fatalf(`runtime JDK layer is not cache only: \n%s`, jdkToml)
+			}
+
+			if buildToml := layerToml(jdk.BuildJdkLayerName); !strings.Contains(buildToml, "build = true") || !strings.Contains(buildToml, "launch = false") {
+				t.Fatalf(`build JDK layer is not build only: \n%s`, buildToml)
+			}
+
+			buildJdk, err := jdk.BuildJdk(layersDir)
+			if err != nil || buildJdk.Version.Major != 11 || buildJdk.Home != layersDir.Layer(jdk.BuildJdkLayerName).Root {
+				t.Fatalf(`build JDK did not match: got %+v`, buildJdk)
+			}
+
+			javaHome, _ := ioutil.ReadFile(filepath.Join(buildJdk.Home, "env.build", "JAVA_HOME.override"))
+			if string(javaHome) != buildJdk.Home {
+				t.Fatalf(`build JAVA_HOME did not match: got %s`, javaHome)
+			}
+		})
+
+		it("should remove the build JDK when the app no longer has one", func() {
+			systemProperties("java.runtime.version=1.8\njava.build.version=11")
+			if _, err := installer.Install(appDir, layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			systemProperties("java.runtime.version=1.8")
+			if _, err := installer.Install(appDir, layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := os.Stat(layersDir.Layer(jdk.BuildJdkLayerName).Root); !os.IsNotExist(err) {
+				t.Fatal("build JDK layer was not removed")
+			}
+
+			buildJdk, err := jdk.BuildJdk(layersDir)
+			if err != nil || buildJdk.Version.Major != 8 {
+				t.Fatalf(`build JDK did not match: got %+v`, buildJdk)
+			}
+
+			if jdkToml := layerToml("jdk"); !strings.Contains(jdkToml, "build = true") {
+				t.Fatalf(`JDK layer is not a build layer: \n%s`, jdkToml)
+			}
+		})
+
+		it("should not install a build JDK for the runtime version", func() {
+			systemProperties("java.runtime.version=11\njava.build.version=11")
+
+			if _, err := installer.Install(appDir, layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			if installer.BuildVersion != (jdk.Version{}) {
+				t.Fatalf(`unexpected build JDK: %+v`, installer.BuildVersion)
+			}
+		})
+	})
+}
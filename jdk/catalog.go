@@ -0,0 +1,226 @@
+package jdk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	CatalogFile = "jdk-versions.toml"
+)
+
+type Catalog struct {
+	DefaultVendor string         `toml:"default_vendor"`
+	DefaultMajor  int            `toml:"default_major"`
+	StackDefaults []StackDefault `toml:"stack_default"`
+	Jdks          []CatalogEntry `toml:"jdk"`
+}
+
+// StackDefault replaces the catalog's default JDK on stacks it isn't published
+// for. The vendor is the catalog's default vendor when it is omitted.
+type StackDefault struct {
+	Stack  string `toml:"stack"`
+	Vendor string `toml:"vendor"`
+	Major  int    `toml:"major"`
+}
+
+type CatalogEntry struct {
+	Vendor   string           `toml:"vendor"`
+	Major    int              `toml:"major"`
+	Lts      bool             `toml:"lts"`
+	Latest   string           `toml:"latest"`
+	Versions []string         `toml:"versions"`
+	Stacks   []string         `toml:"stacks"`
+	Releases []CatalogRelease `toml:"release"`
+}
+
+type CatalogRelease struct {
+	Tag    string `toml:"tag"`
+	Stack  string `toml:"stack"`
+	Sha256 string `toml:"sha256"`
+}
+
+// LoadCatalog reads the JDK catalog shipped in the buildpack, unless
+// JDK_CATALOG_URL points at another catalog by URL or local path.
+func LoadCatalog(buildpackDir string) (Catalog, error) {
+	source := filepath.Join(buildpackDir, CatalogFile)
+	if customSource, ok := os.LookupEnv("JDK_CATALOG_URL"); ok {
+		source = customSource
+	}
+
+	var (
+		r   io.ReadCloser
+		err error
+	)
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "file://") {
+		r, err = openCatalogUrl(source)
+	} else {
+		r, err = os.Open(source)
+	}
+	if err != nil {
+		return Catalog{}, failedToLoadCatalog(source, err)
+	}
+	defer r.Close()
+
+	catalog, err := ReadCatalog(r)
+	if err != nil {
+		return Catalog{}, failedToLoadCatalog(source, err)
+	}
+	return catalog, nil
+}
+
+func ReadCatalog(r io.Reader) (Catalog, error) {
+	var catalog Catalog
+	if _, err := toml.DecodeReader(r, &catalog); err != nil {
+		return Catalog{}, err
+	}
+
+	if catalog.DefaultVendor == "" {
+		catalog.DefaultVendor = DefaultVendor
+	}
+	if catalog.DefaultMajor == 0 {
+		catalog.DefaultMajor = DefaultJdkMajorVersion
+	}
+	return catalog, nil
+}
+
+func openCatalogUrl(url string) (io.ReadCloser, error) {
+	res, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= 300 {
+		res.Body.Close()
+		return nil, errors.New(fmt.Sprintf("Server responded with HTTP %d", res.StatusCode))
+	}
+	return res.Body, nil
+}
+
+func (c Catalog) Entry(vendor string, major int) (CatalogEntry, bool) {
+	for _, entry := range c.Jdks {
+		if normalizeVendor(entry.Vendor) == normalizeVendor(vendor) && entry.Major == major {
+			return entry, true
+		}
+	}
+	return CatalogEntry{}, false
+}
+
+// LatestVersion is the newest tag of a vendor's major version that is
+// published for the stack of the build.
+func (c Catalog) LatestVersion(vendor string, major int) (Version, error) {
+	platform, err := CurrentPlatform()
+	if err != nil {
+		return Version{}, err
+	}
+
+	entry, ok := c.stackEntry(vendor, major, platform.Stack)
+	if !ok {
+		if other, ok := c.Entry(vendor, major); ok && other.LatestTag() != "" {
+			return Version{}, unsupportedStack(other.LatestTag(), platform.Stack, other.Stacks)
+		}
+	}
+	if !ok || entry.LatestTag() == "" {
+		return Version{}, errors.New(fmt.Sprintf("no %s JDK %d in the catalog", normalizeVendor(vendor), major))
+	}
+
+	return Version{
+		Vendor: vendor,
+		Tag:    entry.LatestTag(),
+		Major:  major,
+	}, nil
+}
+
+// stackEntry finds the vendor's major version among the entries published
+// for the stack.
+func (c Catalog) stackEntry(vendor string, major int, stack string) (CatalogEntry, bool) {
+	for _, entry := range c.Jdks {
+		if normalizeVendor(entry.Vendor) == normalizeVendor(vendor) && entry.Major == major && entry.SupportsStack(stack) {
+			return entry, true
+		}
+	}
+	return CatalogEntry{}, false
+}
+
+// DefaultVersion is the JDK installed for apps that don't choose one, which
+// depends on the stack of the build.
+func (c Catalog) DefaultVersion() (Version, error) {
+	platform, err := CurrentPlatform()
+	if err != nil {
+		return Version{}, err
+	}
+
+	for _, d := range c.StackDefaults {
+		if d.Stack != platform.Stack {
+			continue
+		}
+
+		vendor := d.Vendor
+		if vendor == "" {
+			vendor = c.DefaultVendor
+		}
+		return c.LatestVersion(vendor, d.Major)
+	}
+	return c.LatestVersion(c.DefaultVendor, c.DefaultMajor)
+}
+
+func (c Catalog) Checksum(v Version, stack string) string {
+	if entry, ok := c.stackEntry(v.Vendor, v.Major, stack); ok {
+		for _, release := range entry.Releases {
+			if release.Tag == v.Tag && (release.Stack == "" || release.Stack == stack) {
+				return strings.ToLower(release.Sha256)
+			}
+		}
+	}
+	return ""
+}
+
+// Tags lists every tag of the entry available for installation.
+func (e CatalogEntry) Tags() []string {
+	tags := e.Versions
+	if e.Latest != "" && !containsString(tags, e.Latest) {
+		tags = append([]string{e.Latest}, tags...)
+	}
+	return tags
+}
+
+// LatestTag is the entry's explicit latest tag, or else its newest version.
+func (e CatalogEntry) LatestTag() string {
+	if e.Latest != "" {
+		return e.Latest
+	}
+
+	var (
+		latest    string
+		latestNum VersionNumber
+	)
+	for _, tag := range e.Versions {
+		if num, err := ParseVersionNumber(tag); err == nil && (latest == "" || num.Compare(latestNum) > 0) {
+			latest, latestNum = tag, num
+		}
+	}
+	return latest
+}
+
+func (e CatalogEntry) SupportsStack(stack string) bool {
+	return len(e.Stacks) == 0 || containsString(e.Stacks, stack)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeVendor(vendor string) string {
+	return strings.TrimSuffix(vendor, "-")
+}
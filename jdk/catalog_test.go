@@ -0,0 +1,240 @@
+package jdk_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/heroku/java-buildpack/jdk"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestCatalog(t *testing.T) {
+	spec.Run(t, "Catalog", testCatalog, spec.Report(report.Terminal{}))
+}
+
+const testCatalogToml = `
+default_vendor = "openjdk"
+default_major = 11
+
+[[stack_default]]
+stack = "heroku-20"
+vendor = "zulu"
+major = 8
+
+[[jdk]]
+vendor = "openjdk"
+major = 11
+latest = "11.0.2"
+stacks = ["heroku-18"]
+
+  [[jdk.release]]
+  tag = "11.0.2"
+  stack = "heroku-18"
+  sha256 = "ABCDEF"
+
+[[jdk]]
+vendor = "zulu"
+major = 8
+latest = "1.8.0_202"
+`
+
+// testStackEntryToml adds a second entry for openjdk 11, published for
+// another stack.
+const testStackEntryToml = `
+[[jdk]]
+vendor = "openjdk"
+major = 11
+latest = "11.0.2"
+stacks = ["heroku-20"]
+
+  [[jdk.release]]
+  tag = "11.0.2"
+  sha256 = "123456"
+`
+
+func testCatalog(t *testing.T, when spec.G, it spec.S) {
+	var catalog jdk.Catalog
+
+	it.Before(func() {
+		os.Setenv("STACK", "heroku-18")
+
+		var err error
+		catalog, err = jdk.ReadCatalog(strings.NewReader(testCatalogToml))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	when("#DefaultVersion", func() {
+		it("should use the catalog default", func() {
+			v, err := catalog.DefaultVersion()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if v.Major != 11 || v.Tag != "11.0.2" || v.Vendor != "openjdk" {
+				t.Fatalf(`default version did not match: got %+v`, v)
+			}
+		})
+
+		it("should use the stack's default", func() {
+			os.Setenv("STACK", "heroku-20")
+			defer os.Setenv("STACK", "heroku-18")
+
+			v, err := catalog.DefaultVersion()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if v.Major != 8 || v.Tag != "1.8.0_202" || v.Vendor != "zulu" {
+				t.Fatalf(`default version did not match: got %+v`, v)
+			}
+		})
+	})
+
+	when("#ParseVersionString", func() {
+		it("should resolve a major to the latest tag", func() {
+			v, err := catalog.ParseVersionString("11")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if v.Tag != "11.0.2" {
+				t.Fatalf(`JDK version did not match: got %s, want %s`, v.Tag, "11.0.2")
+			}
+		})
+
+		it("should resolve a vendor major to the latest tag", func() {
+			v, err := catalog.ParseVersionString("zulu-8")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if v.Tag != "1.8.0_202" {
+				t.Fatalf(`JDK version did not match: got %s, want %s`, v.Tag, "1.8.0_202")
+			}
+		})
+
+		it("should fail for a major not published for the stack", func() {
+			os.Setenv("STACK", "heroku-16")
+			defer os.Setenv("STACK", "heroku-18")
+
+			_, err := catalog.ParseVersionString("11")
+			if err == nil || !strings.Contains(err.Error(), "heroku-18") {
+				t.Fatalf(`expected an unsupported stack error: got %v`, err)
+			}
+		})
+
+		it("should fail for a major missing from the catalog", func() {
+			if _, err := catalog.ParseVersionString("10"); err == nil {
+				t.Fatal("unexpected success")
+			}
+		})
+	})
+
+	when("#Checksum", func() {
+		it("should find the checksum for the stack", func() {
+			v, _ := catalog.ParseVersionString("11")
+
+			if sum := catalog.Checksum(v, "heroku-18"); sum != "abcdef" {
+				t.Fatalf(`checksum did not match: got %s, want %s`, sum, "abcdef")
+			}
+
+			if sum := catalog.Checksum(v, "heroku-16"); sum != "" {
+				t.Fatalf(`checksum did not match: got %s, want none`, sum)
+			}
+		})
+
+		it("should find the checksum in the entry for the stack", func() {
+			catalog, err := jdk.ReadCatalog(strings.NewReader(testCatalogToml + testStackEntryToml))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			v := jdk.Version{Vendor: "openjdk", Tag: "11.0.2", Major: 11}
+			if sum := catalog.Checksum(v, "heroku-20"); sum != "123456" {
+				t.Fatalf(`checksum did not match: got %s, want %s`, sum, "123456")
+			}
+		})
+	})
+
+	when("#GetVersionUrl", func() {
+		it("should reject stacks the JDK is not published for", func() {
+			v, _ := catalog.ParseVersionString("11")
+
+			os.Setenv("STACK", "heroku-16")
+			defer os.Setenv("STACK", "heroku-18")
+
+			_, err := catalog.GetVersionUrl(v)
+			if err == nil {
+				t.Fatal("unexpected success")
+			}
+
+			if !strings.Contains(err.Error(), "heroku-18") {
+				t.Fatalf(`error does not list supported stacks: %s`, err)
+			}
+		})
+
+		it("should accept stacks published by another entry for the major", func() {
+			catalog, err := jdk.ReadCatalog(strings.NewReader(testCatalogToml + testStackEntryToml))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			os.Setenv("STACK", "heroku-20")
+			defer os.Setenv("STACK", "heroku-18")
+
+			url, err := catalog.GetVersionUrl(jdk.Version{Vendor: "openjdk", Tag: "11.0.2", Major: 11})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(url, "heroku-20") {
+				t.Fatalf(`JDK url is not for the stack: %s`, url)
+			}
+		})
+	})
+
+	when("#LoadCatalog", func() {
+		it("should load a catalog from JDK_CATALOG_URL", func() {
+			dir, err := ioutil.TempDir("", "catalog")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			file := filepath.Join(dir, "catalog.toml")
+			if err := ioutil.WriteFile(file, []byte(testCatalogToml), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			os.Setenv("JDK_CATALOG_URL", file)
+			defer os.Unsetenv("JDK_CATALOG_URL")
+
+			c, err := jdk.LoadCatalog("/does/not/exist")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.DefaultMajor != 11 {
+				t.Fatalf(`default major did not match: got %d, want %d`, c.DefaultMajor, 11)
+			}
+		})
+
+		it("should load the buildpack catalog", func() {
+			wd, _ := os.Getwd()
+			c, err := jdk.LoadCatalog(filepath.Join(wd, ".."))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := c.DefaultVersion(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	})
+}
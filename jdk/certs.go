@@ -0,0 +1,359 @@
+package jdk
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	AppCertsDir        = ".jdk-certs"
+	TruststorePassword = "changeit"
+
+	jksMagic           = 0xFEEDFEED
+	jksVersion         = 2
+	jksPrivateKeyTag   = 1
+	jksTrustedCertTag  = 2
+	jksIntegritySecret = "Mighty Aphrodite"
+)
+
+var (
+	// SystemCertBundle holds the stack's trusted CAs, which the JVM's cacerts
+	// is generated from.
+	SystemCertBundle = "/etc/ssl/certs/ca-certificates.crt"
+)
+
+// AppCert is a CA certificate the app adds to the JVM's truststore.
+type AppCert struct {
+	Cert   *x509.Certificate
+	Source string
+}
+
+// Fingerprint is the certificate's SHA-256 fingerprint.
+func (c AppCert) Fingerprint() string {
+	return fingerprint(c.Cert)
+}
+
+// LoadAppCerts reads the PEM certificates in the app's .jdk-certs directory
+// and in the directory set by JDK_CERTS_DIR.
+func LoadAppCerts(appDir string) ([]AppCert, error) {
+	dirs := []string{filepath.Join(appDir, AppCertsDir)}
+	if envDir, ok := os.LookupEnv("JDK_CERTS_DIR"); ok && envDir != "" {
+		dirs = append(dirs, envDir)
+	}
+
+	var certs []AppCert
+	for _, dir := range dirs {
+		var files []string
+		for _, pattern := range []string{"*.pem", "*.crt"} {
+			matches, err := filepath.Glob(filepath.Join(dir, pattern))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+
+		for _, file := range files {
+			parsed, err := readPemCerts(file)
+			if err != nil {
+				return nil, failedToReadCerts(file, err)
+			}
+
+			source := file
+			if rel, err := filepath.Rel(appDir, file); err == nil && !strings.HasPrefix(rel, "..") {
+				source = rel
+			}
+			for _, cert := range parsed {
+				certs = append(certs, AppCert{Cert: cert, Source: source})
+			}
+		}
+	}
+	return certs, nil
+}
+
+// CertFingerprints identifies a set of app certificates in the layer metadata.
+func CertFingerprints(certs []AppCert) string {
+	var fingerprints []string
+	for _, cert := range certs {
+		fingerprints = append(fingerprints, cert.Fingerprint())
+	}
+	sort.Strings(fingerprints)
+	return strings.Join(fingerprints, ",")
+}
+
+// installTruststore links the JDK's cacerts to the system store, or writes a
+// new cacerts with the system's CAs and the app's certificates when it has any.
+func (i *Installer) installTruststore(jdk Jdk, certs []AppCert) error {
+	if len(certs) == 0 {
+		return InstallCerts(jdk)
+	}
+
+	cacerts := filepath.Join(jdk.Home, "lib", "security", "cacerts")
+	if _, err := os.Stat(filepath.Join(jdk.Home, "jre", "lib", "security")); err == nil {
+		cacerts = filepath.Join(jdk.Home, "jre", "lib", "security", "cacerts")
+	}
+
+	trusted, err := systemCerts(cacerts)
+	if err != nil {
+		return failedToReadCerts(SystemCertBundle, err)
+	}
+
+	for _, cert := range certs {
+		fmt.Fprintf(i.Out, "Adding certificate %s from %s, expires %s\n", cert.Cert.Subject, cert.Source, cert.Cert.NotAfter.Format("2006-01-02"))
+		if time.Now().After(cert.Cert.NotAfter) {
+			fmt.Fprintf(i.Out, "WARNING: certificate %s from %s has expired\n", cert.Cert.Subject, cert.Source)
+		}
+		trusted = append(trusted, cert.Cert)
+	}
+
+	// cacerts may be a symlink to the system store, which must not be changed
+	if err := os.Remove(cacerts); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return WriteTruststore(cacerts, trusted)
+}
+
+// systemCerts reads the stack's CAs, or the JDK's own cacerts when the stack
+// has none.
+func systemCerts(jdkCacerts string) ([]*x509.Certificate, error) {
+	if _, err := os.Stat(SystemCertBundle); err == nil {
+		return readPemCerts(SystemCertBundle)
+	}
+	return ReadTruststore(jdkCacerts)
+}
+
+func readPemCerts(path string) ([]*x509.Certificate, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM certificates found")
+	}
+	return certs, nil
+}
+
+// WriteTruststore writes the certificates to a JKS keystore protected by the
+// JVM's default truststore password. Duplicate certificates are skipped.
+func WriteTruststore(path string, certs []*x509.Certificate) error {
+	buf := &bytes.Buffer{}
+	digest := sha1.New()
+	digest.Write(jksPassword(TruststorePassword))
+	digest.Write([]byte(jksIntegritySecret))
+	w := io.MultiWriter(buf, digest)
+
+	var unique []*x509.Certificate
+	seen := map[string]bool{}
+	for _, cert := range certs {
+		if fp := fingerprint(cert); !seen[fp] {
+			unique = append(unique, cert)
+			seen[fp] = true
+		}
+	}
+
+	binary.Write(w, binary.BigEndian, uint32(jksMagic))
+	binary.Write(w, binary.BigEndian, uint32(jksVersion))
+	binary.Write(w, binary.BigEndian, uint32(len(unique)))
+	for _, cert := range unique {
+		binary.Write(w, binary.BigEndian, uint32(jksTrustedCertTag))
+		writeJksUTF(w, certAlias(cert))
+		binary.Write(w, binary.BigEndian, cert.NotBefore.UnixNano()/int64(time.Millisecond))
+		writeJksUTF(w, "X.509")
+		binary.Write(w, binary.BigEndian, uint32(len(cert.Raw)))
+		w.Write(cert.Raw)
+	}
+	buf.Write(digest.Sum(nil))
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// ReadTruststore reads the trusted certificates of a JKS keystore protected
+// by the JVM's default truststore password, or of the password-less PKCS12
+// keystore JDK 18 and later ship as cacerts.
+func ReadTruststore(path string) ([]*x509.Certificate, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(data) >= 4 && binary.BigEndian.Uint32(data) == jksMagic:
+		return readJks(data)
+	case len(data) > 0 && data[0] == pkcs12Sequence:
+		return readPkcs12(data)
+	}
+	return nil, errors.New("unknown keystore format, expected JKS or PKCS12")
+}
+
+func readJks(data []byte) ([]*x509.Certificate, error) {
+	if len(data) < sha1.Size {
+		return nil, errors.New("truncated keystore")
+	}
+	content, expected := data[:len(data)-sha1.Size], data[len(data)-sha1.Size:]
+
+	digest := sha1.New()
+	digest.Write(jksPassword(TruststorePassword))
+	digest.Write([]byte(jksIntegritySecret))
+	digest.Write(content)
+	if !bytes.Equal(digest.Sum(nil), expected) {
+		return nil, errors.New("keystore integrity check failed")
+	}
+
+	r := bufio.NewReader(bytes.NewReader(content))
+	var magic, version, count uint32
+	for _, v := range []*uint32{&magic, &version, &count} {
+		if err := binary.Read(r, binary.BigEndian, v); err != nil {
+			return nil, err
+		}
+	}
+	if magic != jksMagic || (version != 1 && version != 2) {
+		return nil, errors.New("not a JKS keystore")
+	}
+
+	var certs []*x509.Certificate
+	for n := uint32(0); n < count; n++ {
+		var (
+			tag       uint32
+			timestamp int64
+		)
+		if err := binary.Read(r, binary.BigEndian, &tag); err != nil {
+			return nil, err
+		}
+		if _, err := readJksUTF(r); err != nil {
+			return nil, err
+		}
+		if err := binary.Read(r, binary.BigEndian, &timestamp); err != nil {
+			return nil, err
+		}
+
+		switch tag {
+		case jksTrustedCertTag:
+			der, err := readJksCert(r, version)
+			if err != nil {
+				return nil, err
+			}
+
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, cert)
+		case jksPrivateKeyTag:
+			// a truststore has no keys, but skip them rather than fail
+			if _, err := readJksBytes(r); err != nil {
+				return nil, err
+			}
+
+			var chain uint32
+			if err := binary.Read(r, binary.BigEndian, &chain); err != nil {
+				return nil, err
+			}
+			for c := uint32(0); c < chain; c++ {
+				if _, err := readJksCert(r, version); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, errors.New(fmt.Sprintf("unknown keystore entry type %d", tag))
+		}
+	}
+	return certs, nil
+}
+
+func readJksCert(r io.Reader, version uint32) ([]byte, error) {
+	if version == 2 {
+		if _, err := readJksUTF(r); err != nil {
+			return nil, err
+		}
+	}
+	return readJksBytes(r)
+}
+
+func readJksBytes(r io.Reader) ([]byte, error) {
+	var length uint32
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, length)
+	_, err := io.ReadFull(r, b)
+	return b, err
+}
+
+func readJksUTF(r io.Reader) (string, error) {
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return "", err
+	}
+
+	b := make([]byte, length)
+	_, err := io.ReadFull(r, b)
+	return string(b), err
+}
+
+func writeJksUTF(w io.Writer, s string) {
+	binary.Write(w, binary.BigEndian, uint16(len(s)))
+	w.Write([]byte(s))
+}
+
+// jksPassword encodes the password as Java chars, big-endian UTF-16.
+func jksPassword(password string) []byte {
+	var b []byte
+	for _, c := range utf16.Encode([]rune(password)) {
+		b = append(b, byte(c>>8), byte(c))
+	}
+	return b
+}
+
+// certAlias is a unique ASCII alias, since keystores can't have two entries
+// with the same alias.
+func certAlias(cert *x509.Certificate) string {
+	return fmt.Sprintf("%s [%s]", strings.ToLower(asciiOnly(cert.Subject.CommonName)), fingerprint(cert)[:16])
+}
+
+func asciiOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+func fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
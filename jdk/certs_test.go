@@ -0,0 +1,294 @@
+package jdk_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/heroku/java-buildpack/jdk"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestCerts(t *testing.T) {
+	spec.Run(t, "Certs", testCerts, spec.Report(report.Terminal{}))
+}
+
+func testCert(t *testing.T, cn string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func writePem(t *testing.T, path string, certs ...*x509.Certificate) {
+	var data []byte
+	for _, cert := range certs {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testCerts(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tmpDir    string
+		systemCA  *x509.Certificate
+		corpCA    *x509.Certificate
+		oldBundle string
+	)
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "certs")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		systemCA = testCert(t, "System Root CA")
+		corpCA = testCert(t, "Corp Root CA")
+
+		oldBundle = jdk.SystemCertBundle
+		jdk.SystemCertBundle = filepath.Join(tmpDir, "ca-certificates.crt")
+		writePem(t, jdk.SystemCertBundle, systemCA)
+	})
+
+	it.After(func() {
+		jdk.SystemCertBundle = oldBundle
+		os.Unsetenv("JDK_CERTS_DIR")
+		os.RemoveAll(tmpDir)
+	})
+
+	when("#WriteTruststore", func() {
+		it("should write a keystore that can be read back", func() {
+			path := filepath.Join(tmpDir, "cacerts")
+			if err := jdk.WriteTruststore(path, []*x509.Certificate{systemCA, corpCA, systemCA}); err != nil {
+				t.Fatal(err)
+			}
+
+			certs, err := jdk.ReadTruststore(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(certs) != 2 || !certs[0].Equal(systemCA) || !certs[1].Equal(corpCA) {
+				t.Fatalf(`truststore certificates did not match: got %d certificates`, len(certs))
+			}
+		})
+
+		it("should read the password-less PKCS12 cacerts of JDK 18 and later", func() {
+			certs, err := jdk.ReadTruststore(fixture("cacerts.p12"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(certs) != 2 || certs[0].Subject.CommonName != "Test Root CA 1" || certs[1].Subject.CommonName != "Test Root CA 2" {
+				t.Fatalf(`truststore certificates did not match: got %d certificates`, len(certs))
+			}
+		})
+
+		it("should name the keystore formats it can't read", func() {
+			if _, err := jdk.ReadTruststore(fixture("cacerts-encrypted.p12")); err == nil || !strings.Contains(err.Error(), "PKCS12 keystores with encrypted certificates are not supported") {
+				t.Fatalf(`expected an encrypted PKCS12 error: got %v`, err)
+			}
+
+			path := filepath.Join(tmpDir, "cacerts")
+			ioutil.WriteFile(path, []byte("jdk cacerts"), 0644)
+			if _, err := jdk.ReadTruststore(path); err == nil || !strings.Contains(err.Error(), "expected JKS or PKCS12") {
+				t.Fatalf(`expected an unknown format error: got %v`, err)
+			}
+		})
+
+		it("should detect a corrupted keystore", func() {
+			path := filepath.Join(tmpDir, "cacerts")
+			if err := jdk.WriteTruststore(path, []*x509.Certificate{systemCA}); err != nil {
+				t.Fatal(err)
+			}
+
+			data, _ := ioutil.ReadFile(path)
+			data[20] ^= 0xff
+			ioutil.WriteFile(path, data, 0644)
+
+			if _, err := jdk.ReadTruststore(path); err == nil {
+				t.Fatal("unexpected success")
+			}
+		})
+	})
+
+	when("#LoadAppCerts", func() {
+		it("should read certificates from the app and JDK_CERTS_DIR", func() {
+			appDir := filepath.Join(tmpDir, "app")
+			envDir := filepath.Join(tmpDir, "env")
+			envCA := testCert(t, "Env Root CA")
+			writePem(t, filepath.Join(appDir, ".jdk-certs", "corp.pem"), corpCA)
+			writePem(t, filepath.Join(envDir, "env.crt"), envCA)
+			os.Setenv("JDK_CERTS_DIR", envDir)
+
+			certs, err := jdk.LoadAppCerts(appDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(certs) != 2 || !certs[0].Cert.Equal(corpCA) || !certs[1].Cert.Equal(envCA) {
+				t.Fatalf(`app certificates did not match: got %d certificates`, len(certs))
+			}
+
+			if certs[0].Source != filepath.Join(".jdk-certs", "corp.pem") {
+				t.Fatalf(`certificate source did not match: got %s`, certs[0].Source)
+			}
+		})
+
+		it("should reject files without certificates", func() {
+			appDir := filepath.Join(tmpDir, "app")
+			os.MkdirAll(filepath.Join(appDir, ".jdk-certs"), 0755)
+			ioutil.WriteFile(filepath.Join(appDir, ".jdk-certs", "bad.pem"), []byte("not a certificate"), 0644)
+
+			if _, err := jdk.LoadAppCerts(appDir); err == nil {
+				t.Fatal("unexpected success")
+			}
+		})
+	})
+
+	when("#Install", func() {
+		var (
+			installer *jdk.Installer
+			layersDir layers.Layers
+			server    *httptest.Server
+			handler   http.HandlerFunc
+			downloads int
+			out       *strings.Builder
+		)
+
+		it.Before(func() {
+			downloads = 0
+			handler = countDownloads(&downloads, serveTarball(tarball(
+				tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "lib/security/cacerts", Mode: 0644, Body: "jdk cacerts"},
+			)))
+			server = startMirror(&handler)
+
+			out = &strings.Builder{}
+			installer = newInstaller(out)
+			layersDir = tempLayers(t)
+		})
+
+		it.After(func() {
+			stopMirror(server)
+			os.RemoveAll(layersDir.Root)
+		})
+
+		it("should add the app's certificates to the truststore", func() {
+			appDir := filepath.Join(tmpDir, "app")
+			writePem(t, filepath.Join(appDir, ".jdk-certs", "corp.pem"), corpCA)
+
+			installed, err := installer.Install(appDir, layersDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			certs, err := jdk.ReadTruststore(filepath.Join(installed.Home, "lib", "security", "cacerts"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(certs) != 2 || !certs[0].Equal(systemCA) || !certs[1].Equal(corpCA) {
+				t.Fatalf(`truststore certificates did not match: got %d certificates`, len(certs))
+			}
+
+			if !strings.Contains(out.String(), "Adding certificate CN=Corp Root CA from .jdk-certs/corp.pem, expires") {
+				t.Fatalf(`added certificate not reported: %s`, out)
+			}
+
+			jreCerts, err := jdk.ReadTruststore(filepath.Join(layersDir.Layer("jre").Root, "lib", "security", "cacerts"))
+			if err != nil || len(jreCerts) != 2 {
+				t.Fatalf(`JRE truststore did not match: %v`, err)
+			}
+		})
+
+		it("should add the app's certificates to a PKCS12 cacerts when the stack has none", func() {
+			os.Remove(jdk.SystemCertBundle)
+
+			jdkCacerts, err := ioutil.ReadFile(fixture("cacerts.p12"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			handler = serveTarball(tarball(
+				tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "lib/security/cacerts", Mode: 0644, Body: string(jdkCacerts)},
+			))
+
+			appDir := filepath.Join(tmpDir, "app")
+			writePem(t, filepath.Join(appDir, ".jdk-certs", "corp.pem"), corpCA)
+
+			installed, err := installer.Install(appDir, layersDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			certs, err := jdk.ReadTruststore(filepath.Join(installed.Home, "lib", "security", "cacerts"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(certs) != 3 || certs[0].Subject.CommonName != "Test Root CA 1" || !certs[2].Equal(corpCA) {
+				t.Fatalf(`truststore certificates did not match: got %d certificates`, len(certs))
+			}
+		})
+
+		it("should reinstall when the app's certificates change", func() {
+			appDir := filepath.Join(tmpDir, "app")
+			writePem(t, filepath.Join(appDir, ".jdk-certs", "corp.pem"), corpCA)
+
+			install := func(expected int) {
+				if _, err := installer.Install(appDir, layersDir); err != nil {
+					t.Fatal(err)
+				}
+
+				if downloads != expected {
+					t.Fatalf(`JDK downloads did not match: got %d, want %d`, downloads, expected)
+				}
+			}
+
+			install(1)
+			install(1)
+			writePem(t, filepath.Join(appDir, ".jdk-certs", "corp.pem"), corpCA, testCert(t, "Other CA"))
+			install(2)
+		})
+	})
+}
@@ -1,8 +1,15 @@
 package jdk
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
 )
 
 const (
@@ -19,6 +26,208 @@ func errorWithCause(message string, cause error) error {
 	return errors.New(fmt.Sprintf(errorFmt, message, cause))
 }
 
-func invalidJdkVersion(version string, url string) error {
-	return errorWithCause(fmt.Sprintf("Invalid JDK version: %s", version), errors.New(fmt.Sprintf("Failed to reach %s", url)))
+func unsupportedStack(version, stack string, stacks []string) error {
+	return errorWithCause(fmt.Sprintf("JDK %s is not available for stack %s", version, stack), errors.New(fmt.Sprintf("Supported stacks are: %s", strings.Join(stacks, ", "))))
+}
+
+func missingStack(stacks []string) error {
+	return errorWithCause("The STACK is not set", errors.New(fmt.Sprintf("Supported stacks are: %s", strings.Join(stacks, ", "))))
+}
+
+func unknownStack(stack string, stacks []string) error {
+	return errorWithCause(fmt.Sprintf("Unknown stack: %s", stack), errors.New(fmt.Sprintf("Supported stacks are: %s", strings.Join(stacks, ", "))))
+}
+
+func unsupportedArch(stack, arch string, arches []string) error {
+	return errorWithCause(fmt.Sprintf("JDKs are not available for %s on stack %s", arch, stack), errors.New(fmt.Sprintf("Supported architectures are: %s", strings.Join(arches, ", "))))
+}
+
+func unknownVendor(vendor string, vendors []string) error {
+	return errorWithCause(fmt.Sprintf("Unknown JDK vendor: %s", vendor), errors.New(fmt.Sprintf("Supported vendors are: %s", strings.Join(vendors, ", "))))
+}
+
+func failedToLoadCatalog(source string, cause error) error {
+	return errorWithCause(fmt.Sprintf("Failed to load the JDK catalog from %s", source), cause)
+}
+
+func invalidJdkChecksum(url, expected, actual string) error {
+	return errorWithCause(fmt.Sprintf("JDK checksum verification failed for %s", url), errors.New(fmt.Sprintf("expected SHA-256 %s but downloaded %s", expected, actual)))
+}
+
+func failedToReadCerts(path string, cause error) error {
+	return errorWithCause(fmt.Sprintf("Failed to read certificates from %s", path), cause)
+}
+
+func invalidOverlay(file string, cause error) error {
+	return errorWithCause(fmt.Sprintf("Failed to apply %s/%s to the JDK", JdkOverlayDir, file), cause)
+}
+
+func failedToConfigureSecurity(path string, cause error) error {
+	return errorWithCause(fmt.Sprintf("Failed to configure java.security from %s", path), cause)
+}
+
+func failedToLinkRuntime(cause error) error {
+	return errorWithCause("Failed to link a runtime with jlink", cause)
+}
+
+type DownloadError struct {
+	Url        string
+	StatusCode int
+	Cause      error
+}
+
+func (e *DownloadError) Error() string {
+	cause := e.Cause
+	if cause == nil {
+		cause = errors.New(fmt.Sprintf("Server responded with HTTP %d", e.StatusCode))
+	}
+	return fmt.Sprintf(errorFmt, fmt.Sprintf("Failed to download JDK from %s", e.Url), cause)
+}
+
+// Temporary is whether retrying the download could succeed: after a 5xx or
+// 429 response, a timeout, or a refused or reset connection. Other failures,
+// like TLS errors or hosts that don't resolve, fail the same way again.
+func (e *DownloadError) Temporary() bool {
+	if e.StatusCode == 0 {
+		var netErr net.Error
+		if errors.As(e.Cause, &netErr) && netErr.Timeout() {
+			return true
+		}
+		return errors.Is(e.Cause, syscall.ECONNREFUSED) || errors.Is(e.Cause, syscall.ECONNRESET)
+	}
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// proxyAuthFailed is whether an HTTPS proxy refused the CONNECT request, which
+// the client reports as an error rather than a response.
+func (e *DownloadError) proxyAuthFailed() bool {
+	return e.Cause != nil && strings.Contains(e.Cause.Error(), http.StatusText(http.StatusProxyAuthRequired))
+}
+
+// tlsFailed is whether the mirror's certificate couldn't be verified or the TLS
+// handshake failed, which no retry fixes.
+func (e *DownloadError) tlsFailed() bool {
+	var (
+		verifyErr   *tls.CertificateVerificationError
+		hostnameErr x509.HostnameError
+		recordErr   tls.RecordHeaderError
+		alertErr    tls.AlertError
+	)
+	return errors.As(e.Cause, &verifyErr) || errors.As(e.Cause, &hostnameErr) || errors.As(e.Cause, &recordErr) ||
+		errors.As(e.Cause, &alertErr) || errors.Is(e.Cause, http.ErrSchemeMismatch)
+}
+
+// UnknownVersionError means the JDK isn't published for the stack, usually
+// because the requested version has a typo or is too new for the mirror.
+type UnknownVersionError struct {
+	Version Version
+	Stack   string
+	Url     string
+}
+
+func (e *UnknownVersionError) Error() string {
+	return fmt.Sprintf(errorFmt, fmt.Sprintf("Invalid JDK version: %s is not available for stack %s", e.Version.Tag, e.Stack), fmt.Sprintf("%s was not found", e.Url))
+}
+
+// MirrorUnreachableError means the JDK mirror couldn't be reached at all, for
+// example because DNS resolution failed or the connection timed out.
+type MirrorUnreachableError struct {
+	Url      string
+	Attempts int
+	Cause    error
+}
+
+func (e *MirrorUnreachableError) Error() string {
+	return fmt.Sprintf(errorFmt, fmt.Sprintf("Failed to reach the JDK mirror for %s after %s", e.Url, pluralAttempts(e.Attempts)), e.Cause)
+}
+
+// TlsError means the JDK mirror was reached but no secure connection could be
+// made, usually because its certificate isn't trusted by the build.
+type TlsError struct {
+	Url   string
+	Cause error
+}
+
+func (e *TlsError) Error() string {
+	return fmt.Sprintf(errorFmt, fmt.Sprintf("Failed to make a secure connection to the JDK mirror for %s", e.Url), e.Cause)
+}
+
+// ServerError means the JDK mirror kept failing with 5xx or 429 responses.
+type ServerError struct {
+	Url        string
+	StatusCode int
+	Attempts   int
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf(errorFmt, fmt.Sprintf("The JDK mirror failed to serve %s after %s", e.Url, pluralAttempts(e.Attempts)), fmt.Sprintf("Server responded with HTTP %d, try again later", e.StatusCode))
+}
+
+func pluralAttempts(attempts int) string {
+	if attempts == 1 {
+		return "1 attempt"
+	}
+	return fmt.Sprintf("%d attempts", attempts)
+}
+
+// ProxyAuthError means a proxy or the mirror refused the request's credentials.
+type ProxyAuthError struct {
+	Url        string
+	StatusCode int
+}
+
+func (e *ProxyAuthError) Error() string {
+	hint := "check the credentials for the JDK mirror, or that the JDK version exists"
+	if e.StatusCode == http.StatusProxyAuthRequired {
+		hint = "check the proxy credentials in HTTP_PROXY and HTTPS_PROXY"
+	}
+	return fmt.Sprintf(errorFmt, fmt.Sprintf("Access to %s was denied", e.Url), fmt.Sprintf("Server responded with HTTP %d, %s", e.StatusCode, hint))
+}
+
+// classifyDownloadError explains why a JDK download failed, after the given
+// number of attempts. Errors other than a DownloadError are returned as is.
+func classifyDownloadError(err error, v Version, stack string, attempts int) error {
+	dlErr, ok := err.(*DownloadError)
+	if !ok {
+		return err
+	}
+
+	switch {
+	case dlErr.StatusCode == http.StatusNotFound:
+		return &UnknownVersionError{Version: v, Stack: stack, Url: dlErr.Url}
+	case dlErr.StatusCode == http.StatusForbidden && !proxyConfigured():
+		// S3 answers 403 rather than 404 for keys that don't exist
+		return &UnknownVersionError{Version: v, Stack: stack, Url: dlErr.Url}
+	case dlErr.StatusCode == http.StatusUnauthorized || dlErr.StatusCode == http.StatusForbidden || dlErr.StatusCode == http.StatusProxyAuthRequired:
+		return &ProxyAuthError{Url: dlErr.Url, StatusCode: dlErr.StatusCode}
+	case dlErr.proxyAuthFailed():
+		return &ProxyAuthError{Url: dlErr.Url, StatusCode: http.StatusProxyAuthRequired}
+	case dlErr.StatusCode == 0 && dlErr.tlsFailed():
+		return &TlsError{Url: dlErr.Url, Cause: dlErr.Cause}
+	case dlErr.StatusCode == 0:
+		return &MirrorUnreachableError{Url: dlErr.Url, Attempts: attempts, Cause: dlErr.Cause}
+	case dlErr.Temporary():
+		return &ServerError{Url: dlErr.Url, StatusCode: dlErr.StatusCode, Attempts: attempts}
+	}
+	return dlErr
+}
+
+// proxyConfigured is whether the build goes through an HTTP proxy, which could
+// be the one refusing a request.
+func proxyConfigured() bool {
+	for _, name := range []string{"HTTPS_PROXY", "https_proxy", "HTTP_PROXY", "http_proxy"} {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+type ExtractError struct {
+	Path  string
+	Cause error
+}
+
+func (e *ExtractError) Error() string {
+	return fmt.Sprintf(errorFmt, fmt.Sprintf("Failed to extract JDK to %s", e.Path), e.Cause)
 }
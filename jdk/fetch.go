@@ -0,0 +1,221 @@
+package jdk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/heroku/java-buildpack/util"
+)
+
+var (
+	// httpClient also reads file:// URLs, for installs from a local mirror
+	httpClient = util.NewHttpClient()
+)
+
+func (i *Installer) fetchJdk(v Version, stack, jdkUrl, expectedSha256 string, layout ArchiveLayout, layer layers.Layer) (string, error) {
+	tarball, err := ioutil.TempFile("", "jdk")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tarball.Name())
+	defer tarball.Close()
+
+	var actualSha256 string
+	attempts, err := i.Retry.Do(i.Out, "JDK download", func() (err error) {
+		actualSha256, err = i.download(jdkUrl, tarball)
+		return err
+	})
+	if err != nil {
+		return "", classifyDownloadError(err, v, stack, attempts)
+	}
+
+	if expectedSha256 == "" {
+		fmt.Fprintf(i.Out, "WARNING: No checksum published for %s, the JDK is installed without verification\n", jdkUrl)
+	} else if !strings.EqualFold(expectedSha256, actualSha256) {
+		return "", invalidJdkChecksum(jdkUrl, expectedSha256, actualSha256)
+	}
+
+	if err := util.ResetDir(layer.Root); err != nil {
+		return "", &ExtractError{Path: layer.Root, Cause: err}
+	}
+
+	if _, err := tarball.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	if err := extractTarGz(tarball, layer.Root, layout); err != nil {
+		return "", &ExtractError{Path: layer.Root, Cause: err}
+	}
+	return actualSha256, nil
+}
+
+func (i *Installer) download(url string, out *os.File) (string, error) {
+	if err := out.Truncate(0); err != nil {
+		return "", err
+	}
+	if _, err := out.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	res, err := httpClient.Get(url)
+	if err != nil {
+		return "", &DownloadError{Url: url, Cause: err}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return "", &DownloadError{Url: url, StatusCode: res.StatusCode}
+	}
+
+	body := &progressReader{
+		Reader: res.Body,
+		Out:    i.Out,
+		Total:  res.ContentLength,
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, h), body); err != nil {
+		if body.Err != nil {
+			return "", &DownloadError{Url: url, Cause: body.Err}
+		}
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checkJdkUrl makes sure the JDK is published before anything is downloaded.
+func (i *Installer) checkJdkUrl(v Version, stack, jdkUrl string) error {
+	attempts, err := i.Retry.Do(i.Out, "JDK lookup", func() error {
+		return headUrl(jdkUrl)
+	})
+	return classifyDownloadError(err, v, stack, attempts)
+}
+
+func headUrl(url string) error {
+	res, err := httpClient.Head(url)
+	if err != nil {
+		return &DownloadError{Url: url, Cause: err}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return &DownloadError{Url: url, StatusCode: res.StatusCode}
+	}
+	return nil
+}
+
+func (i *Installer) fetchChecksum(v Version, stack, checksumUrl string) (string, error) {
+	var checksum string
+	attempts, err := i.Retry.Do(i.Out, "checksum download", func() (err error) {
+		checksum, err = fetchChecksum(checksumUrl)
+		return err
+	})
+	if err != nil {
+		return "", classifyDownloadError(err, v, stack, attempts)
+	}
+	return checksum, nil
+}
+
+// fetchChecksum reads the SHA-256 digest published next to the tarball. An
+// empty digest is returned when the mirror does not publish one.
+func fetchChecksum(checksumUrl string) (string, error) {
+	res, err := httpClient.Get(checksumUrl)
+	if err != nil {
+		return "", &DownloadError{Url: checksumUrl, Cause: err}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusForbidden {
+		return "", nil
+	} else if res.StatusCode >= 300 {
+		return "", &DownloadError{Url: checksumUrl, StatusCode: res.StatusCode}
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	if err != nil {
+		return "", &DownloadError{Url: checksumUrl, Cause: err}
+	}
+
+	checksum, err := parseChecksum(string(body))
+	if err != nil {
+		return "", errorWithCause(fmt.Sprintf("Failed to read the JDK checksum from %s", checksumUrl), err)
+	}
+	return checksum, nil
+}
+
+func parseChecksum(s string) (string, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return "", errors.New("empty checksum")
+	}
+
+	digest := strings.ToLower(fields[0])
+	if _, err := hex.DecodeString(digest); err != nil || len(digest) != sha256.Size*2 {
+		return "", errors.New(fmt.Sprintf("malformed SHA-256 checksum: %s", fields[0]))
+	}
+	return digest, nil
+}
+
+func extractTarGz(r io.Reader, dest string, layout ArchiveLayout) error {
+	return util.ExtractTarGz(r, dest, layout.relativePath)
+}
+
+// relativePath maps a path in the archive to its path in the JDK home, or
+// reports false when the entry is outside of the home.
+func (l ArchiveLayout) relativePath(name string) (string, bool) {
+	parts := strings.Split(strings.TrimPrefix(filepath.ToSlash(filepath.Clean(name)), "./"), "/")
+	if len(parts) <= l.StripComponents {
+		return "", false
+	}
+	rel := path.Join(parts[l.StripComponents:]...)
+
+	if l.Home != "" {
+		home := path.Clean(l.Home)
+		if rel == home {
+			return ".", true
+		} else if !strings.HasPrefix(rel, home+"/") {
+			return "", false
+		}
+		rel = strings.TrimPrefix(rel, home+"/")
+	}
+	return rel, true
+}
+
+type progressReader struct {
+	Reader   io.Reader
+	Out      io.Writer
+	Total    int64
+	Err      error
+	read     int64
+	reported int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.Reader.Read(b)
+	if err != nil && err != io.EOF {
+		p.Err = err
+	}
+
+	p.read += int64(n)
+	if p.Out != nil && p.Total > 0 {
+		if percent := p.read * 100 / p.Total; percent >= p.reported+10 {
+			p.reported = percent - percent%10
+			fmt.Fprintf(p.Out, "Downloaded %.1f MB of %.1f MB (%d%%)\n", megabytes(p.read), megabytes(p.Total), p.reported)
+		}
+	}
+	return n, err
+}
+
+func megabytes(n int64) float64 {
+	return float64(n) / (1024 * 1024)
+}
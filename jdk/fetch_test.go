@@ -0,0 +1,593 @@
+package jdk_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/heroku/java-buildpack/jdk"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestFetch(t *testing.T) {
+	spec.Run(t, "Fetch", testFetch, spec.Report(report.Terminal{}))
+	spec.Run(t, "Mirror", testMirror, spec.Report(report.Terminal{}))
+	spec.Run(t, "DownloadError", testDownloadError, spec.Report(report.Terminal{}))
+}
+
+func testFetch(t *testing.T, when spec.G, it spec.S) {
+	var (
+		installer *jdk.Installer
+		layersDir layers.Layers
+		server    *httptest.Server
+		handler   http.HandlerFunc
+	)
+
+	it.Before(func() {
+		server = startMirror(&handler)
+		installer = newInstaller(ioutil.Discard)
+		installer.Retry = jdk.RetryPolicy{Attempts: 3, Delay: time.Millisecond}
+		layersDir = tempLayers(t)
+	})
+
+	it.After(func() {
+		stopMirror(server)
+		os.RemoveAll(layersDir.Root)
+	})
+
+	when("#Install", func() {
+		it("should extract files, permissions and symlinks", func() {
+			handler = serveTarball(tarball(
+				tarEntry{Name: "bin/", Mode: 0755, Type: tar.TypeDir},
+				tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "jre/bin/java", Link: "../../bin/java", Type: tar.TypeSymlink},
+			))
+
+			if _, err := installer.Install(fixture("app_with_pom"), layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			fi, err := os.Stat(filepath.Join(layersDir.Layer("jdk").Root, "bin", "java"))
+			if err != nil {
+				t.Fatal("java not installed")
+			}
+
+			if fi.Mode().Perm() != 0755 {
+				t.Fatalf(`java permissions did not match: got %s, want %s`, fi.Mode().Perm(), os.FileMode(0755))
+			}
+
+			link, err := os.Readlink(filepath.Join(layersDir.Layer("jdk").Root, "jre", "bin", "java"))
+			if err != nil {
+				t.Fatal("symlink not created")
+			}
+
+			if link != "../../bin/java" {
+				t.Fatalf(`symlink target did not match: got %s, want %s`, link, "../../bin/java")
+			}
+		})
+
+		for _, id := range jdk.StackIDs() {
+			stack, _ := jdk.LookupStack(id)
+			for arch := range stack.Paths {
+				id, arch := id, arch
+				it("should install the default JDK on "+id+" for "+arch, func() {
+					os.Setenv("STACK", id)
+					os.Setenv("JDK_ARCH", arch)
+					defer os.Setenv("STACK", "heroku-18")
+					defer os.Unsetenv("JDK_ARCH")
+
+					handler = serveTarball(tarball(tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"}))
+
+					appDir, err := ioutil.TempDir("", "app")
+					if err != nil {
+						t.Fatal(err)
+					}
+					defer os.RemoveAll(appDir)
+
+					installed, err := installer.Install(appDir, layersDir)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					if !strings.HasPrefix(installed.Url, server.URL+"/"+stack.Paths[arch]+"/") {
+						t.Fatalf(`JDK URL did not match the stack: got %s`, installed.Url)
+					}
+				})
+			}
+		}
+
+		it("should retry transient failures", func() {
+			attempts := 0
+			serve := serveTarball(tarball(tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"}))
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, ".tar.gz") {
+					attempts++
+					if attempts == 1 {
+						w.WriteHeader(http.StatusServiceUnavailable)
+						return
+					}
+				}
+				serve(w, r)
+			}
+
+			if _, err := installer.Install(fixture("app_with_pom"), layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			if attempts != 2 {
+				t.Fatalf(`download attempts did not match: got %d, want %d`, attempts, 2)
+			}
+		})
+
+		it("should not retry missing files", func() {
+			attempts := 0
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					if strings.HasSuffix(r.URL.Path, ".tar.gz") {
+						attempts++
+					}
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}
+
+			_, err := installer.Install(fixture("app_with_pom"), layersDir)
+			if err == nil {
+				t.Fatal("unexpected success")
+			}
+
+			if attempts > 1 {
+				t.Fatalf(`download attempts did not match: got %d, want at most %d`, attempts, 1)
+			}
+		})
+
+		it("should report versions missing for the stack", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			}
+
+			_, err := installer.Install(fixture("app_with_pom"), layersDir)
+			if e, ok := err.(*jdk.UnknownVersionError); !ok || e.Stack != "heroku-18" {
+				t.Fatalf(`expected an UnknownVersionError: got %v`, err)
+			}
+		})
+
+		it("should report server errors after retrying", func() {
+			attempts := 0
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				w.WriteHeader(http.StatusBadGateway)
+			}
+
+			_, err := installer.Install(fixture("app_with_pom"), layersDir)
+			if e, ok := err.(*jdk.ServerError); !ok || e.StatusCode != http.StatusBadGateway || e.Attempts != 3 {
+				t.Fatalf(`expected a ServerError after 3 attempts: got %v`, err)
+			}
+
+			if attempts != 3 {
+				t.Fatalf(`attempts did not match: got %d, want %d`, attempts, 3)
+			}
+		})
+
+		it("should report a forbidden download as a missing version without a proxy", func() {
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusForbidden)
+			}
+
+			_, err := installer.Install(fixture("app_with_pom"), layersDir)
+			if _, ok := err.(*jdk.UnknownVersionError); !ok {
+				t.Fatalf(`expected an UnknownVersionError: got %v`, err)
+			}
+		})
+
+		it("should report proxy and auth failures without retrying", func() {
+			os.Setenv("HTTPS_PROXY", "http://proxy.example.com:3128")
+			defer os.Unsetenv("HTTPS_PROXY")
+
+			for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusProxyAuthRequired} {
+				attempts := 0
+				handler = func(w http.ResponseWriter, r *http.Request) {
+					attempts++
+					w.WriteHeader(status)
+				}
+
+				_, err := installer.Install(fixture("app_with_pom"), layersDir)
+				if e, ok := err.(*jdk.ProxyAuthError); !ok || e.StatusCode != status {
+					t.Fatalf(`expected a ProxyAuthError for HTTP %d: got %v`, status, err)
+				}
+
+				if attempts != 1 {
+					t.Fatalf(`attempts did not match: got %d, want %d`, attempts, 1)
+				}
+			}
+		})
+
+		it("should report an unreachable mirror after retrying", func() {
+			server.Close()
+
+			_, err := installer.Install(fixture("app_with_pom"), layersDir)
+			if e, ok := err.(*jdk.MirrorUnreachableError); !ok || e.Attempts != 3 {
+				t.Fatalf(`expected a MirrorUnreachableError after 3 attempts: got %v`, err)
+			}
+		})
+
+		it("should count a single attempt in the error", func() {
+			installer.Retry = jdk.RetryPolicy{Attempts: 1}
+			server.Close()
+
+			_, err := installer.Install(fixture("app_with_pom"), layersDir)
+			if err == nil || !strings.Contains(err.Error(), "after 1 attempt\n") {
+				t.Fatalf(`expected an error after 1 attempt: got %v`, err)
+			}
+		})
+
+		it("should not retry TLS failures", func() {
+			os.Setenv("DEFAULT_JDK_BASE_URL", strings.Replace(server.URL, "http://", "https://", 1))
+
+			_, err := installer.Install(fixture("app_with_pom"), layersDir)
+			if _, ok := err.(*jdk.TlsError); !ok {
+				t.Fatalf(`expected a TlsError: got %v`, err)
+			}
+		})
+
+		it("should report mirrors with untrusted certificates", func() {
+			attempts := 0
+			tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+			}))
+			defer tlsServer.Close()
+			os.Setenv("DEFAULT_JDK_BASE_URL", tlsServer.URL)
+
+			_, err := installer.Install(fixture("app_with_pom"), layersDir)
+			if e, ok := err.(*jdk.TlsError); !ok || !strings.Contains(e.Error(), "certificate") {
+				t.Fatalf(`expected a TlsError for the certificate: got %v`, err)
+			}
+
+			if attempts != 0 {
+				t.Fatalf(`attempts did not match: got %d, want %d`, attempts, 0)
+			}
+		})
+
+		it("should warn when no checksum is published", func() {
+			out := &bytes.Buffer{}
+			installer.Out = out
+			handler = serveTarball(tarball(tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"}))
+
+			installed, err := installer.Install(fixture("app_with_pom"), layersDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(out.String(), "WARNING: No checksum published for "+installed.Url) {
+				t.Fatalf(`expected a warning about the missing checksum: got %s`, out)
+			}
+		})
+
+		it("should report a malformed published checksum with its URL", func() {
+			handler = serveTarballWithChecksum(tarball(tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"}), "not-a-checksum")
+
+			_, err := installer.Install(fixture("app_with_pom"), layersDir)
+			if err == nil || !strings.Contains(err.Error(), server.URL) || !strings.Contains(err.Error(), ".sha256") || !strings.Contains(err.Error(), "malformed SHA-256 checksum") {
+				t.Fatalf(`expected a checksum error with its URL: got %v`, err)
+			}
+		})
+
+		it("should verify and record the published checksum", func() {
+			data := tarball(tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"})
+			sum := sha256.Sum256(data)
+			expected := hex.EncodeToString(sum[:])
+			handler = serveTarballWithChecksum(data, expected)
+
+			if _, err := installer.Install(fixture("app_with_pom"), layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			var jdkMetadata jdk.Jdk
+			if err := layersDir.Layer("jdk").ReadMetadata(&jdkMetadata); err != nil {
+				t.Fatal("Layer metadata was not written")
+			}
+
+			if jdkMetadata.Sha256 != expected {
+				t.Fatalf(`Jdk.Sha256 did not match: got %s, want %s`, jdkMetadata.Sha256, expected)
+			}
+		})
+
+		it("should fail when the checksum does not match", func() {
+			data := tarball(tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"})
+			handler = serveTarballWithChecksum(data, strings.Repeat("0", 64))
+
+			_, err := installer.Install(fixture("app_with_pom"), layersDir)
+			if err == nil {
+				t.Fatal("unexpected success")
+			}
+
+			if !strings.Contains(err.Error(), "checksum verification failed") {
+				t.Fatalf(`unexpected error: %s`, err)
+			}
+
+			if _, err := os.Stat(filepath.Join(layersDir.Layer("jdk").Root, "bin", "java")); !os.IsNotExist(err) {
+				t.Fatal("unverified JDK was installed")
+			}
+		})
+
+		it("should reuse a cached JDK with matching metadata", func() {
+			downloads := 0
+			serve := serveTarball(tarball(tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"}))
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, ".tar.gz") {
+					downloads++
+				}
+				serve(w, r)
+			}
+
+			for n := 0; n < 2; n++ {
+				if _, err := installer.Install(fixture("app_with_pom"), layersDir); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if downloads != 1 {
+				t.Fatalf(`JDK downloads did not match: got %d, want %d`, downloads, 1)
+			}
+
+			layerToml, err := ioutil.ReadFile(layersDir.Layer("jdk").Metadata)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(string(layerToml), "cache = true") {
+				t.Fatalf(`JDK layer is not cached: \n%s`, layerToml)
+			}
+		})
+
+		it("should reuse a cached JDK without network access", func() {
+			handler = serveTarball(tarball(tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"}))
+
+			if _, err := installer.Install(fixture("app_with_pom"), layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			server.Close()
+
+			if _, err := installer.Install(fixture("app_with_pom"), layersDir); err != nil {
+				t.Fatalf(`cached JDK was not reused offline: %s`, err)
+			}
+		})
+
+		it("should reinstall when the version changes", func() {
+			downloads := 0
+			serve := serveTarball(tarball(tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"}))
+			handler = func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, ".tar.gz") {
+					downloads++
+				}
+				serve(w, r)
+			}
+
+			for _, app := range []string{"app_with_pom", "app_with_jdk_version"} {
+				if _, err := installer.Install(fixture(app), layersDir); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if downloads != 2 {
+				t.Fatalf(`JDK downloads did not match: got %d, want %d`, downloads, 2)
+			}
+		})
+
+		it("should reject paths outside of the layer", func() {
+			handler = serveTarball(tarball(tarEntry{Name: "../evil", Mode: 0644, Body: "evil"}))
+
+			_, err := installer.Install(fixture("app_with_pom"), layersDir)
+			if _, ok := err.(*jdk.ExtractError); !ok {
+				t.Fatalf(`expected an ExtractError: got %v`, err)
+			}
+
+			if _, err := os.Stat(filepath.Join(layersDir.Root, "evil")); !os.IsNotExist(err) {
+				t.Fatal("file written outside of the layer")
+			}
+		})
+	})
+}
+
+func testDownloadError(t *testing.T, when spec.G, it spec.S) {
+	when("#Temporary", func() {
+		for _, tc := range []struct {
+			name      string
+			err       *jdk.DownloadError
+			temporary bool
+		}{
+			{"a server error", &jdk.DownloadError{StatusCode: http.StatusBadGateway}, true},
+			{"too many requests", &jdk.DownloadError{StatusCode: http.StatusTooManyRequests}, true},
+			{"a refused connection", &jdk.DownloadError{Cause: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}}, true},
+			{"a reset connection", &jdk.DownloadError{Cause: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}}, true},
+			{"a timeout", &jdk.DownloadError{Cause: &net.DNSError{Err: "i/o timeout", Name: "mirror.example.com", IsTimeout: true}}, true},
+			{"a missing file", &jdk.DownloadError{StatusCode: http.StatusNotFound}, false},
+			{"an unknown host", &jdk.DownloadError{Cause: &net.DNSError{Err: "no such host", Name: "mirror.example.com", IsNotFound: true}}, false},
+			{"a TLS failure", &jdk.DownloadError{Cause: errors.New("x509: certificate signed by unknown authority")}, false},
+		} {
+			tc := tc
+			it(fmt.Sprintf("should be %t for %s", tc.temporary, tc.name), func() {
+				if tc.err.Temporary() != tc.temporary {
+					t.Fatalf(`Temporary() did not match: got %t, want %t`, !tc.temporary, tc.temporary)
+				}
+			})
+		}
+	})
+}
+
+func testMirror(t *testing.T, when spec.G, it spec.S) {
+	var (
+		installer *jdk.Installer
+		layersDir layers.Layers
+		mirrorDir string
+		tag       string
+	)
+
+	it.Before(func() {
+		os.Setenv("STACK", "heroku-18")
+		installer = newInstaller(ioutil.Discard)
+
+		catalog, err := jdk.LoadCatalog(installer.BuildpackDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		latest, _ := catalog.LatestVersion(jdk.DefaultVendor, 8)
+		tag = latest.Tag
+
+		mirrorDir, err = ioutil.TempDir("", "mirror")
+		if err != nil {
+			t.Fatal(err)
+		}
+		layersDir = tempLayers(t)
+	})
+
+	it.After(func() {
+		os.Unsetenv("DEFAULT_JDK_BASE_URL")
+		os.Unsetenv("JDK_MIRROR_DIR")
+		os.RemoveAll(mirrorDir)
+		os.RemoveAll(layersDir.Root)
+	})
+
+	seed := func(path string, data []byte, checksum string) {
+		path = filepath.Join(mirrorDir, path)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if checksum != "" {
+			ioutil.WriteFile(path+".sha256", []byte(checksum), 0644)
+		}
+	}
+
+	when("#Install", func() {
+		it("should install from a file:// base URL", func() {
+			data := tarball(tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"})
+			sum := sha256.Sum256(data)
+			seed(filepath.Join("heroku-18", "openjdk"+tag+".tar.gz"), data, hex.EncodeToString(sum[:]))
+			os.Setenv("DEFAULT_JDK_BASE_URL", "file://"+mirrorDir)
+
+			installed, err := installer.Install(fixture("app_with_pom"), layersDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if installed.Sha256 != hex.EncodeToString(sum[:]) {
+				t.Fatalf(`JDK checksum did not match: got %s, want %x`, installed.Sha256, sum)
+			}
+
+			if _, err := os.Stat(filepath.Join(installed.Home, "bin", "java")); err != nil {
+				t.Fatal("java not installed")
+			}
+		})
+
+		it("should install from a mirror directory", func() {
+			data := tarball(tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"})
+			seed(filepath.Join("heroku-18", "openjdk", tag+".tar.gz"), data, "")
+			os.Setenv("JDK_MIRROR_DIR", mirrorDir)
+
+			installed, err := installer.Install(fixture("app_with_pom"), layersDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.HasPrefix(installed.Url, "file://") {
+				t.Fatalf(`JDK was not installed from the mirror: %s`, installed.Url)
+			}
+
+			if _, err := os.Stat(filepath.Join(installed.Home, "bin", "java")); err != nil {
+				t.Fatal("java not installed")
+			}
+		})
+
+		it("should verify checksums in a mirror directory", func() {
+			data := tarball(tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"})
+			seed(filepath.Join("heroku-18", "openjdk", tag+".tar.gz"), data, strings.Repeat("0", 64))
+			os.Setenv("JDK_MIRROR_DIR", mirrorDir)
+
+			if _, err := installer.Install(fixture("app_with_pom"), layersDir); err == nil || !strings.Contains(err.Error(), "checksum") {
+				t.Fatalf(`expected a checksum error: got %v`, err)
+			}
+		})
+
+		it("should reject versions missing from a mirror directory", func() {
+			os.Setenv("JDK_MIRROR_DIR", mirrorDir)
+
+			if _, err := installer.Install(fixture("app_with_pom"), layersDir); err == nil {
+				t.Fatal("unexpected success")
+			} else if _, ok := err.(*jdk.UnknownVersionError); !ok {
+				t.Fatalf(`expected an UnknownVersionError: got %v`, err)
+			}
+		})
+	})
+}
+
+type tarEntry struct {
+	Name string
+	Mode int64
+	Type byte
+	Body string
+	Link string
+}
+
+func tarball(entries ...tarEntry) []byte {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+
+	for _, e := range entries {
+		typeflag := e.Type
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+		tw.WriteHeader(&tar.Header{
+			Name:     e.Name,
+			Mode:     e.Mode,
+			Typeflag: typeflag,
+			Linkname: e.Link,
+			Size:     int64(len(e.Body)),
+		})
+		tw.Write([]byte(e.Body))
+	}
+
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
+
+func serveTarball(data []byte) http.HandlerFunc {
+	return serveTarballWithChecksum(data, "")
+}
+
+func serveTarballWithChecksum(data []byte, checksum string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".sha256") {
+			if checksum == "" {
+				w.WriteHeader(http.StatusNotFound)
+			} else {
+				fmt.Fprintf(w, "%s  %s\n", checksum, path.Base(strings.TrimSuffix(r.URL.Path, ".sha256")))
+			}
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(data)))
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	}
+}
@@ -4,7 +4,6 @@ package jdk_test
 
 import (
 	"crypto/sha256"
-	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
@@ -53,8 +52,6 @@ func testIntegrationJdk(t *testing.T, when spec.G, it spec.S) {
 			t.Fatal(err)
 		}
 
-		os.Setenv("PATH", fmt.Sprintf("%s:%s", os.Getenv("PATH"), filepath.Join(wd, "..", "bin")))
-
 		installer = &jdk.Installer{
 			In:           []byte{},
 			Out:          os.Stdout,
@@ -94,11 +91,11 @@ func testIntegrationJdk(t *testing.T, when spec.G, it spec.S) {
 				t.Fatal("cacerts not linked")
 			}
 
-			if _, err := os.Stat(filepath.Join(layersDir.Layer("jdk").Root, "profile.d", "jvm.sh")); os.IsNotExist(err) {
+			if _, err := os.Stat(filepath.Join(layersDir.Layer("jre").Root, "profile.d", "jvm.sh")); os.IsNotExist(err) {
 				t.Fatal("JVM profile.d script not installed")
 			}
 
-			if _, err := os.Stat(filepath.Join(layersDir.Layer("jdk").Root, "profile.d", "jdbc.sh")); os.IsNotExist(err) {
+			if _, err := os.Stat(filepath.Join(layersDir.Layer("jre").Root, "profile.d", "jdbc.sh")); os.IsNotExist(err) {
 				t.Fatal("JDBC profile.d script not installed")
 			}
 
@@ -115,8 +112,9 @@ func testIntegrationJdk(t *testing.T, when spec.G, it spec.S) {
 				t.Fatalf(`Jdk.Version.Tag did not match: got %d, want %d`, jdkMetadata.Version.Major, 8)
 			}
 
-			if jdkMetadata.Version.Tag != jdk.DefaultVersionStrings[8] {
-				t.Fatalf(`Jdk.Version.Tag did not match: got %s, want %s`, jdkMetadata.Version.Tag, jdk.DefaultVersionStrings[8])
+			latest, _ := installer.Catalog.LatestVersion(jdk.DefaultVendor, 8)
+			if jdkMetadata.Version.Tag != latest.Tag {
+				t.Fatalf(`Jdk.Version.Tag did not match: got %s, want %s`, jdkMetadata.Version.Tag, latest.Tag)
 			}
 
 			if jdkMetadata.Version.Vendor != jdk.DefaultVendor {
@@ -143,11 +141,11 @@ func testIntegrationJdk(t *testing.T, when spec.G, it spec.S) {
 				t.Fatal("cacerts not linked")
 			}
 
-			if _, err := os.Stat(filepath.Join(layersDir.Layer("jdk").Root, "profile.d", "jvm.sh")); os.IsNotExist(err) {
+			if _, err := os.Stat(filepath.Join(layersDir.Layer("jre").Root, "profile.d", "jvm.sh")); os.IsNotExist(err) {
 				t.Fatal("JVM profile.d script not installed")
 			}
 
-			if _, err := os.Stat(filepath.Join(layersDir.Layer("jdk").Root, "profile.d", "jdbc.sh")); os.IsNotExist(err) {
+			if _, err := os.Stat(filepath.Join(layersDir.Layer("jre").Root, "profile.d", "jdbc.sh")); os.IsNotExist(err) {
 				t.Fatal("JDBC profile.d script not installed")
 			}
 
@@ -164,8 +162,9 @@ func testIntegrationJdk(t *testing.T, when spec.G, it spec.S) {
 				t.Fatalf(`Jdk.Version.Tag did not match: got %d, want %d`, jdkMetadata.Version.Major, 11)
 			}
 
-			if jdkMetadata.Version.Tag != jdk.DefaultVersionStrings[11] {
-				t.Fatalf(`Jdk.Version.Tag did not match: got %s, want %s`, jdkMetadata.Version.Tag, jdk.DefaultVersionStrings[11])
+			latest, _ := installer.Catalog.LatestVersion(jdk.DefaultVendor, 11)
+			if jdkMetadata.Version.Tag != latest.Tag {
+				t.Fatalf(`Jdk.Version.Tag did not match: got %s, want %s`, jdkMetadata.Version.Tag, latest.Tag)
 			}
 
 			if jdkMetadata.Version.Vendor != jdk.DefaultVendor {
@@ -197,6 +196,19 @@ func testIntegrationJdk(t *testing.T, when spec.G, it spec.S) {
 			if actual != expected {
 				t.Fatalf("cacerts not copied from jdk-overlay: got %s, want %s", actual, expected)
 			}
+
+			if _, err := os.Stat(filepath.Join(layersDir.Layer(jdk.JreLayerName).Root, "test.txt")); os.IsNotExist(err) {
+				t.Fatal("jdk-overlay files not found in the JRE")
+			}
+
+			actual, err = calcSha256(filepath.Join(layersDir.Layer(jdk.JreLayerName).Root, "lib", "security", "cacerts"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if actual != expected {
+				t.Fatalf("cacerts not copied from jdk-overlay to the JRE: got %s, want %s", actual, expected)
+			}
 		})
 	})
 }
@@ -5,13 +5,14 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/buildpack/libbuildpack/buildplan"
 	"github.com/buildpack/libbuildpack/layers"
 	"github.com/heroku/java-buildpack/util"
 )
@@ -21,11 +22,32 @@ type Installer struct {
 	Out, Err     io.Writer
 	Version      Version
 	BuildpackDir string
+	Catalog      Catalog
+	// BuildVersion is the JDK the app is compiled with when it differs from Version
+	BuildVersion Version
+	// Retry is the policy for transient download failures, DefaultRetryPolicy when unset
+	Retry RetryPolicy
+	// Plan holds the build plan entries of the buildpacks that ran before this one
+	Plan buildplan.BuildPlan
 }
 
 type Jdk struct {
 	Version Version `toml:"version"`
 	Home    string  `toml:"home"`
+	Url     string  `toml:"url"`
+	Sha256  string  `toml:"sha256"`
+	// Modules lists the modules of a runtime created by jlink
+	Modules string `toml:"modules,omitempty"`
+	// Certs lists the fingerprints of the app's certificates in the truststore
+	Certs string `toml:"certs,omitempty"`
+	// Overlay lists the files copied from the app's .jdk-overlay
+	Overlay []OverlayFile `toml:"overlay,omitempty"`
+	// Security holds the properties set in java.security
+	Security map[string]string `toml:"security,omitempty"`
+	// Stack and InstalledAt record where and when the JDK was installed for the
+	// bill of materials
+	Stack       string    `toml:"stack,omitempty"`
+	InstalledAt time.Time `toml:"installed_at"`
 }
 
 type Version struct {
@@ -40,16 +62,13 @@ const (
 	DefaultJdkBaseUrl      = "https://lang-jvm.s3.amazonaws.com/jdk"
 )
 
-var (
-	DefaultVersionStrings = map[int]string{
-		8:  "1.8.0_191",
-		9:  "9.0.4",
-		10: "10.0.2",
-		11: "11.0.1",
+func (i *Installer) Init(appDir string) error {
+	catalog, err := LoadCatalog(i.BuildpackDir)
+	if err != nil {
+		return err
 	}
-)
+	i.Catalog = catalog
 
-func (i *Installer) Init(appDir string) error {
 	v, err := i.detectVersion(appDir)
 	if err != nil {
 		return err
@@ -57,74 +76,205 @@ func (i *Installer) Init(appDir string) error {
 
 	i.Version = v
 
+	buildVersion, err := i.detectBuildVersion(appDir, v)
+	if err != nil {
+		return err
+	}
+
+	i.BuildVersion = buildVersion
+
 	return nil
 }
 
 func (i *Installer) Install(appDir string, layersDir layers.Layers) (Jdk, error) {
-	i.Init(appDir)
-	// check the build plan to see if another JDK has already been installed?
+	if err := i.Init(appDir); err != nil {
+		return Jdk{}, err
+	}
 
-	jdkUrl, err := GetVersionUrl(i.Version)
+	certs, err := LoadAppCerts(appDir)
 	if err != nil {
 		return Jdk{}, err
 	}
 
-	if !IsValidJdkUrl(jdkUrl) {
-		return Jdk{}, invalidJdkVersion(i.Version.Tag, jdkUrl)
+	security, err := LoadSecuritySettings(appDir)
+	if err != nil {
+		return Jdk{}, err
 	}
 
-	jdkLayer := layersDir.Layer("jdk")
-	jdk := Jdk{
-		Home:    jdkLayer.Root,
-		Version: i.Version,
+	overlayDir := filepath.Join(appDir, JdkOverlayDir)
+	overlay, err := ReadOverlay(overlayDir)
+	if err != nil {
+		return Jdk{}, err
 	}
 
-	if err := i.fetchJdk(jdkUrl, jdkLayer); err != nil {
+	jdkLayer := layersDir.Layer("jdk")
+	jdk, reusable, err := i.installJdk(i.Version, jdkLayer, certs, security, overlayDir, overlay)
+	if err != nil {
 		return jdk, err
 	}
 
-	if err := InstallCerts(jdk); err != nil {
+	launchLayer := jdkLayer
+	flags := []layers.Flag{layers.Cache, layers.Launch}
+	jreLayer := layersDir.Layer(JreLayerName)
+	if RuntimeJdk() {
+		if err := removeLayer(jreLayer); err != nil {
+			return jdk, err
+		}
+	} else {
+		// with jlink, the linker replaces the JRE after the build, so it
+		// installs the JRE only when the runtime can't be linked
+		if !jlinkEnabled(appDir) {
+			jre, err := i.installJre(jdk, jreLayer, !reusable, overlayDir)
+			if err != nil {
+				return jdk, err
+			}
+
+			if err := jre.WriteMetadata(jreLayer, layers.Launch, layers.Cache); err != nil {
+				return jdk, err
+			}
+		}
+		launchLayer = jreLayer
+		flags = []layers.Flag{layers.Cache}
+	}
+
+	if err := CreateProfileScripts(i.BuildpackDir, launchLayer); err != nil {
 		return jdk, err
 	}
 
-	if err := CreateProfileScripts(i.BuildpackDir, jdkLayer); err != nil {
+	buildLayer := jdkLayer
+	buildJdkLayer := layersDir.Layer(BuildJdkLayerName)
+	if i.BuildVersion != (Version{}) {
+		// the app's overlay customizes its runtime, so only the runtime JDK gets it
+		buildJdk, _, err := i.installJdk(i.BuildVersion, buildJdkLayer, certs, security, overlayDir, nil)
+		if err != nil {
+			return jdk, err
+		}
+
+		if err := buildJdk.WriteMetadata(buildJdkLayer, layers.Build, layers.Cache); err != nil {
+			return jdk, err
+		}
+		buildLayer = buildJdkLayer
+	} else {
+		if err := removeLayer(buildJdkLayer); err != nil {
+			return jdk, err
+		}
+		flags = append(flags, layers.Build)
+	}
+
+	// later buildpacks build with the build JDK, the app launches with its runtime
+	if err := buildLayer.OverrideBuildEnv("JAVA_HOME", "%s", buildLayer.Root); err != nil {
+		return jdk, err
+	}
+	if err := launchLayer.OverrideLaunchEnv("JAVA_HOME", "%s", launchLayer.Root); err != nil {
 		return jdk, err
 	}
 
 	// TODO install pgconfig
 	// TODO install metrics agent
 
-	if err := i.applyJdkOverlay(jdkLayer, appDir); err != nil {
+	if err := jdk.WriteMetadata(jdkLayer, flags...); err != nil {
 		return jdk, err
 	}
 
-	if err := jdk.WriteMetadata(jdkLayer); err != nil {
-		return jdk, err
+	return jdk, nil
+}
+
+// installJdk installs a JDK version into a layer, unless the layer already has
+// it. It returns whether the cached JDK was reused.
+func (i *Installer) installJdk(v Version, layer layers.Layer, certs []AppCert, security map[string]string, overlayDir string, overlay []OverlayFile) (Jdk, bool, error) {
+	jdkUrl, err := i.Catalog.GetVersionUrl(v)
+	if err != nil {
+		return Jdk{}, false, err
 	}
 
-	return jdk, nil
+	provider, err := LookupVendor(v.Vendor)
+	if err != nil {
+		return Jdk{}, false, err
+	}
+
+	platform, err := CurrentPlatform()
+	if err != nil {
+		return Jdk{}, false, err
+	}
+
+	// the cached JDK is checked before any network access, so a reusable JDK
+	// is used even when the download mirror can't be reached
+	jdk := Jdk{
+		Home:     layer.Root,
+		Version:  v,
+		Url:      jdkUrl,
+		Sha256:   i.Catalog.Checksum(v, platform.Stack),
+		Certs:    CertFingerprints(certs),
+		Overlay:  overlay,
+		Security: security,
+		Stack:    platform.Stack,
+	}
+
+	var cached Jdk
+	if err := layer.ReadMetadata(&cached); err != nil {
+		return jdk, false, err
+	}
+
+	if jdk.IsReusable(cached) {
+		fmt.Fprintf(i.Out, "Using cached JDK %s\n", jdk.Version.Tag)
+		jdk.Sha256 = cached.Sha256
+		jdk.InstalledAt = cached.InstalledAt
+		return jdk, true, nil
+	}
+
+	if err := i.checkJdkUrl(v, platform.Stack, jdkUrl); err != nil {
+		return jdk, false, err
+	}
+
+	expectedSha256 := jdk.Sha256
+	if checksumUrl := provider.ChecksumUrl(jdkUrl); expectedSha256 == "" && checksumUrl != "" {
+		if expectedSha256, err = i.fetchChecksum(v, platform.Stack, checksumUrl); err != nil {
+			return jdk, false, err
+		}
+	}
+
+	jdk.InstalledAt = time.Now().UTC().Truncate(time.Second)
+
+	if jdk.Sha256, err = i.fetchJdk(v, platform.Stack, jdkUrl, expectedSha256, provider.Layout(), layer); err != nil {
+		return jdk, false, err
+	}
+
+	if err := i.installTruststore(jdk, certs); err != nil {
+		return jdk, false, err
+	}
+
+	if err := i.applyJdkOverlay(jdk.Home, overlayDir, overlay); err != nil {
+		return jdk, false, err
+	}
+
+	if err := i.configureSecurity(jdk, security); err != nil {
+		return jdk, false, err
+	}
+	return jdk, false, nil
 }
 
-func (jdk Jdk) WriteMetadata(layer layers.Layer) error {
-	return layer.WriteMetadata(jdk, layers.Launch)
+func (jdk Jdk) WriteMetadata(layer layers.Layer, flags ...layers.Flag) error {
+	return layer.WriteMetadata(jdk, flags...)
 }
 
-func (i *Installer) fetchJdk(jdkUrl string, layer layers.Layer) error {
-	cmd := exec.Command(filepath.Join("jdk-fetcher"), jdkUrl, layer.Root)
-	cmd.Env = os.Environ()
-	cmd.Stdout = i.Out
-	cmd.Stderr = i.Err
+// IsReusable reports whether a previously installed JDK can be used as-is,
+// which requires the same download, certificates, overlay and java.security
+// settings.
+func (jdk Jdk) IsReusable(cached Jdk) bool {
+	if cached.Version != jdk.Version || cached.Url != jdk.Url || cached.Home != jdk.Home || cached.Certs != jdk.Certs || cached.Modules != jdk.Modules {
+		return false
+	}
 
-	return cmd.Run()
-}
+	if jdk.Sha256 != "" && !strings.EqualFold(cached.Sha256, jdk.Sha256) {
+		return false
+	}
 
-func (i *Installer) applyJdkOverlay(layer layers.Layer, appDir string) error {
-	cmd := exec.Command(filepath.Join("jdk-overlay"), layer.Root, filepath.Join(appDir, ".jdk-overlay"))
-	cmd.Env = os.Environ()
-	cmd.Stdout = i.Out
-	cmd.Stderr = i.Err
+	if !sameOverlay(cached.Overlay, jdk.Overlay) || !sameSettings(cached.Security, jdk.Security) {
+		return false
+	}
 
-	return cmd.Run()
+	_, err := os.Stat(filepath.Join(jdk.Home, "bin", "java"))
+	return err == nil
 }
 
 func (i *Installer) detectVersion(appDir string) (Version, error) {
@@ -132,14 +282,72 @@ func (i *Installer) detectVersion(appDir string) (Version, error) {
 	if _, err := os.Stat(systemPropertiesFile); !os.IsNotExist(err) {
 		sysProps, err := util.ReadPropertiesFile(systemPropertiesFile)
 		if err != nil {
-			return defaultVersion(), nil
+			return i.Catalog.DefaultVersion()
 		}
 
 		if version, ok := sysProps["java.runtime.version"]; ok {
-			return ParseVersionString(version)
+			fmt.Fprintf(i.Out, "Using JDK %s from java.runtime.version in system.properties\n", version)
+			return i.appVersion(version)
+		}
+	}
+
+	if version, file, ok := versionFile(appDir); ok {
+		fmt.Fprintf(i.Out, "Using JDK %s from %s\n", version, file)
+		return i.appVersion(version)
+	}
+
+	if version, ok := planVersion(i.Plan); ok {
+		fmt.Fprintf(i.Out, "Using JDK %s requested in the build plan\n", version)
+		return i.Catalog.ParseVersionString(version)
+	}
+
+	// the pom.xml is only a hint, so fall back to the default when the
+	// catalog has no such JDK for the stack
+	if pomVersion, ok := detectPomVersion(appDir); ok {
+		v, err := i.Catalog.ParseVersionString(pomVersion.Version)
+		if err == nil {
+			fmt.Fprintf(i.Out, "Using JDK %s\n", pomVersion)
+			return v, nil
+		}
+		fmt.Fprintf(i.Out, "WARNING: JDK %s is not available on this stack, using the default JDK instead\n", pomVersion)
+	}
+
+	v, err := i.Catalog.DefaultVersion()
+	if err == nil {
+		fmt.Fprintf(i.Out, "Using default JDK %s, set java.runtime.version in system.properties to choose another\n", v.Tag)
+	}
+	return v, err
+}
+
+// appVersion parses a version the app chose, which takes precedence over one
+// requested in the build plan.
+func (i *Installer) appVersion(version string) (Version, error) {
+	v, err := i.Catalog.ParseVersionString(version)
+	if err == nil {
+		if substitutedRelease(version, v) {
+			fmt.Fprintf(i.Out, "WARNING: JDK %s is not in the catalog, using JDK %s instead\n", version, v.Tag)
 		}
+		i.reconcilePlan(v)
+	}
+	return v, err
+}
+
+// substitutedRelease is whether a vendor's release, like temurin-17.0.8, was
+// missing from the catalog and resolved to another release of its major.
+func substitutedRelease(version string, v Version) bool {
+	m := vendorVersionPattern.FindStringSubmatch(version)
+	if m == nil || IsVersionConstraint(m[2]) {
+		return false
+	} else if _, err := strconv.Atoi(m[2]); err == nil {
+		return false
+	}
+
+	constraint, err := ParseVersionConstraint("=" + m[2])
+	if err != nil {
+		return false
 	}
-	return defaultVersion(), nil
+	num, err := ParseVersionNumber(v.Tag)
+	return err == nil && !constraint.Matches(num)
 }
 
 func InstallCerts(jdk Jdk) error {
@@ -168,129 +376,133 @@ func CreateProfileScripts(buildpackDir string, layer layers.Layer) error {
 	if err != nil {
 		return err
 	}
-	layer.WriteProfile("jvm.sh", string(jvmProfiled))
+	layer.WriteProfile("jvm.sh", "%s", jvmProfiled)
 
 	jdbcProfiled, err := ioutil.ReadFile(filepath.Join(buildpackDir, "profile.d", "jdbc.sh"))
 	if err != nil {
 		return err
 	}
-	layer.WriteProfile("jdbc.sh", string(jdbcProfiled))
+	layer.WriteProfile("jdbc.sh", "%s", jdbcProfiled)
 
 	return nil
 }
 
-func defaultVersion() Version {
-	version, _ := ParseVersionString(DefaultVersionStrings[DefaultJdkMajorVersion])
-	return version
-}
+var (
+	majorVersionPattern  = regexp.MustCompile(`^(?:1\.)?([0-9]+)$`)
+	vendorVersionPattern = regexp.MustCompile(`^([a-z]+)-(.*)$`)
+)
 
-func ParseVersionString(v string) (Version, error) {
-	if v == "10" || v == "11" {
-		major, _ := strconv.Atoi(v)
-		return ParseVersionString(DefaultVersionStrings[major])
-	} else if m := regexp.MustCompile("^(1[0-1])\\.").FindAllStringSubmatch(v, -1); len(m) == 1 {
-		major, _ := strconv.Atoi(m[0][1])
-		return Version{
-			Vendor: DefaultVendor,
-			Tag:    v,
-			Major:  major,
-		}, nil
-	} else if m := regexp.MustCompile("^1\\.([7-9])$").FindAllStringSubmatch(v, -1); len(m) == 1 {
-		major, _ := strconv.Atoi(m[0][1])
-		return Version{
-			Vendor: DefaultVendor,
-			Tag:    DefaultVersionStrings[major],
-			Major:  major,
-		}, nil
-	} else if m := regexp.MustCompile("^([7-9])$").FindAllStringSubmatch(v, -1); len(m) == 1 {
-		major, _ := strconv.Atoi(m[0][1])
-		return Version{
-			Vendor: DefaultVendor,
-			Tag:    DefaultVersionStrings[major],
-			Major:  major,
-		}, nil
-	} else if m := regexp.MustCompile("^1\\.([7-9])").FindAllStringSubmatch(v, -1); len(m) == 1 {
-		major, _ := strconv.Atoi(m[0][1])
-		return Version{
-			Vendor: DefaultVendor,
-			Tag:    v,
-			Major:  major,
-		}, nil
+func (c Catalog) ParseVersionString(v string) (Version, error) {
+	if IsVersionConstraint(v) {
+		return c.resolveConstraint(DefaultVendor, v)
 	} else if v == "9+181" || v == "9.0.0" {
 		return Version{
 			Vendor: DefaultVendor,
 			Tag:    "9-181",
 			Major:  9,
 		}, nil
-	} else if m := regexp.MustCompile("^9\\.").FindAllStringSubmatch(v, -1); len(m) == 1 {
+	} else if m := majorVersionPattern.FindStringSubmatch(v); m != nil {
+		major, _ := strconv.Atoi(m[1])
+		return c.LatestVersion(DefaultVendor, major)
+	} else if m := vendorVersionPattern.FindStringSubmatch(v); m != nil {
+		if _, err := LookupVendor(m[1]); err != nil {
+			return Version{}, err
+		}
+
+		vendor := m[1]
+		if vendor == "zulu" {
+			vendor = "zulu-"
+		}
+		return c.vendorVersion(vendor, m[2])
+	} else if num, err := ParseVersionNumber(v); err == nil {
 		return Version{
 			Vendor: DefaultVendor,
 			Tag:    v,
-			Major:  9,
-		}, nil
-	} else if m := regexp.MustCompile("^zulu-(.*)").FindAllStringSubmatch(v, -1); len(m) == 1 {
-		return Version{
-			Vendor: "zulu-",
-			Tag:    m[0][1],
-			Major:  parseMajorVersion(m[0][1]),
-		}, nil
-	} else if m := regexp.MustCompile("^openjdk-(.*)").FindAllStringSubmatch(v, -1); len(m) == 1 {
-		return Version{
-			Vendor: "openjdk",
-			Tag:    m[0][1],
-			Major:  parseMajorVersion(m[0][1]),
+			Major:  num.Major(),
 		}, nil
 	}
 
 	return Version{}, errors.New("unparseable version string")
 }
 
-func GetVersionUrl(v Version) (string, error) {
-	baseUrl := DefaultJdkBaseUrl
-	if customBaseUrl, ok := os.LookupEnv("DEFAULT_JDK_BASE_URL"); ok {
-		baseUrl = customBaseUrl
+func (c Catalog) resolveConstraint(vendor, v string) (Version, error) {
+	constraint, err := ParseVersionConstraint(v)
+	if err != nil {
+		return Version{}, err
 	}
+	return c.Resolve(vendor, constraint)
+}
 
-	stack, ok := os.LookupEnv("STACK")
-	if !ok {
-		return "", errors.New("missing stack")
+func (c Catalog) vendorVersion(vendor, tag string) (Version, error) {
+	if IsVersionConstraint(tag) {
+		return c.resolveConstraint(vendor, tag)
+	} else if major, err := strconv.Atoi(tag); err == nil {
+		if _, ok := c.Entry(vendor, major); ok {
+			return c.LatestVersion(vendor, major)
+		}
+	} else if constraint, err := ParseVersionConstraint("=" + tag); err == nil {
+		// version managers often omit the build number the vendor's tag has
+		if v, err := c.Resolve(vendor, constraint); err == nil {
+			return v, nil
+		} else if c.hasBuildNumbers(vendor) && !hasBuildNumber(tag) {
+			// the download can't be found without the build number, so the
+			// latest release of the major stands in for it
+			return c.LatestVersion(vendor, parseMajorVersion(tag))
+		}
 	}
 
-	vendor := v.Vendor
-	if v.Vendor == "zulu" {
-		vendor = "zulu-"
+	return Version{
+		Vendor: vendor,
+		Tag:    tag,
+		Major:  parseMajorVersion(tag),
+	}, nil
+}
+
+// hasBuildNumbers is whether the vendor's tags in the catalog have build
+// numbers, like Temurin's 17.0.9+9.
+func (c Catalog) hasBuildNumbers(vendor string) bool {
+	for _, entry := range c.Jdks {
+		if normalizeVendor(entry.Vendor) != normalizeVendor(vendor) {
+			continue
+		}
+		for _, tag := range entry.Tags() {
+			if hasBuildNumber(tag) {
+				return true
+			}
+		}
 	}
+	return false
+}
 
-	return fmt.Sprintf("%s/%s/%s%s.tar.gz", baseUrl, stack, vendor, v.Tag), nil
+func hasBuildNumber(tag string) bool {
+	num, err := ParseVersionNumber(tag)
+	return err == nil && num.Build != 0
 }
 
-func IsValidJdkUrl(url string) bool {
-	res, err := http.Head(url)
+// GetVersionUrl finds the JDK's download for the platform of the build.
+func (c Catalog) GetVersionUrl(v Version) (string, error) {
+	platform, err := CurrentPlatform()
 	if err != nil {
-		return false
+		return "", err
+	}
+
+	if _, ok := c.stackEntry(v.Vendor, v.Major, platform.Stack); !ok {
+		if entry, ok := c.Entry(v.Vendor, v.Major); ok {
+			return "", unsupportedStack(v.Tag, platform.Stack, entry.Stacks)
+		}
 	}
-	return res.StatusCode < 300
+
+	provider, err := LookupVendor(v.Vendor)
+	if err != nil {
+		return "", err
+	}
+	return provider.Url(v, platform)
 }
 
 func parseMajorVersion(tag string) int {
-	if m := regexp.MustCompile("^1\\.7").FindAllStringSubmatch(tag, -1); len(m) == 1 {
-		return 7
-	} else if m := regexp.MustCompile("^1\\.8").FindAllStringSubmatch(tag, -1); len(m) == 1 {
-		return 8
-	} else if m := regexp.MustCompile("^1\\.9").FindAllStringSubmatch(tag, -1); len(m) == 1 {
-		return 9
-	} else if m := regexp.MustCompile("^7").FindAllStringSubmatch(tag, -1); len(m) == 1 {
-		return 7
-	} else if m := regexp.MustCompile("^8").FindAllStringSubmatch(tag, -1); len(m) == 1 {
-		return 8
-	} else if m := regexp.MustCompile("^9").FindAllStringSubmatch(tag, -1); len(m) == 1 {
-		return 9
-	} else if m := regexp.MustCompile("^10").FindAllStringSubmatch(tag, -1); len(m) == 1 {
-		return 10
-	} else if m := regexp.MustCompile("^11").FindAllStringSubmatch(tag, -1); len(m) == 1 {
-		return 11
-	} else {
-		major, _ := strconv.Atoi(tag)
-		return major
+	if num, err := ParseVersionNumber(tag); err == nil {
+		return num.Major()
 	}
+	major, _ := strconv.Atoi(tag)
+	return major
 }
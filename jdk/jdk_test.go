@@ -1,9 +1,14 @@
 package jdk_test
 
 import (
+	"bytes"
+	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/buildpack/libbuildpack/layers"
@@ -80,15 +85,25 @@ func testJdkInstaller(t *testing.T, when spec.G, it spec.S) {
 	var (
 		installer *jdk.Installer
 		layersDir layers.Layers
+		catalog   jdk.Catalog
 	)
 
 	it.Before(func() {
+		wd, _ := os.Getwd()
+
 		os.Setenv("STACK", "heroku-18")
 
 		installer = &jdk.Installer{
-			In:  []byte{},
-			Out: os.Stdout,
-			Err: os.Stderr,
+			In:           []byte{},
+			Out:          os.Stdout,
+			Err:          os.Stderr,
+			BuildpackDir: filepath.Join(wd, ".."),
+		}
+
+		var err error
+		catalog, err = jdk.LoadCatalog(installer.BuildpackDir)
+		if err != nil {
+			t.Fatal(err)
 		}
 
 		layersRoot, err := ioutil.TempDir("", "layers")
@@ -115,11 +130,207 @@ func testJdkInstaller(t *testing.T, when spec.G, it spec.S) {
 				t.Fatalf(`JDK version did not match: got %s, want %s`, installer.Version.Tag, expected)
 			}
 		})
+
+		for _, tc := range []struct {
+			name     string
+			pom      string
+			expected int
+		}{
+			{"maven.compiler.release", `<properties><maven.compiler.release>17</maven.compiler.release></properties>`, 17},
+			{"the newest of source and target", `<properties><maven.compiler.source>1.8</maven.compiler.source><maven.compiler.target>11</maven.compiler.target></properties>`, 11},
+			{"java.version", `<properties><java.version>11</java.version></properties>`, 11},
+			{"the compiler plugin release", `<properties><java.version>11</java.version><jdk>17</jdk></properties>
+				<build><plugins><plugin>
+					<artifactId>maven-compiler-plugin</artifactId>
+					<configuration><release>${jdk}</release></configuration>
+				</plugin></plugins></build>`, 17},
+			{"nothing", `<properties><project.build.sourceEncoding>UTF-8</project.build.sourceEncoding></properties>`, 8},
+		} {
+			tc := tc
+			it("should detect the jdk version from "+tc.name+" in pom.xml", func() {
+				appDir, err := ioutil.TempDir("", "app")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer os.RemoveAll(appDir)
+
+				pom := "<project>" + tc.pom + "</project>"
+				if err := ioutil.WriteFile(filepath.Join(appDir, "pom.xml"), []byte(pom), 0644); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := installer.Init(appDir); err != nil {
+					t.Fatal(err)
+				}
+
+				if installer.Version.Major != tc.expected {
+					t.Fatalf(`JDK version did not match: got %d, want %d`, installer.Version.Major, tc.expected)
+				}
+			})
+		}
+
+		it("should fall back to the default jdk when the pom.xml version is not on the stack", func() {
+			os.Setenv("STACK", "heroku-22")
+
+			appDir, err := ioutil.TempDir("", "app")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(appDir)
+
+			pom := "<project><properties><maven.compiler.source>1.8</maven.compiler.source><maven.compiler.target>1.8</maven.compiler.target></properties></project>"
+			if err := ioutil.WriteFile(filepath.Join(appDir, "pom.xml"), []byte(pom), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			out := &bytes.Buffer{}
+			installer.Out = out
+
+			if err := installer.Init(appDir); err != nil {
+				t.Fatal(err)
+			}
+
+			if installer.Version.Major != 17 {
+				t.Fatalf(`JDK version did not match: got %d, want 17`, installer.Version.Major)
+			}
+
+			if !strings.Contains(out.String(), "WARNING: JDK 1.8 (from") {
+				t.Fatalf(`expected a warning about the pom.xml version: got %s`, out)
+			}
+		})
+
+		for _, tc := range []struct {
+			file    string
+			content string
+			vendor  string
+			tag     string
+		}{
+			{".java-version", "11.0.1\n", "openjdk", "11.0.1"},
+			{".java-version", "temurin64-17.0.9\n", "temurin", "17.0.9+9"},
+			{".sdkmanrc", "# Enable auto-env\njava=17.0.8.8.1-amzn\n", "corretto", "17.0.8.8.1"},
+			{".sdkmanrc", "java=21.0.1-tem\n", "temurin", "21.0.1+12"},
+			{".tool-versions", "nodejs 18.17.1\njava temurin-17.0.9+9 corretto-17.0.8.8.1\n", "temurin", "17.0.9+9"},
+			{".tool-versions", "java adoptopenjdk-11.0.21+9\n", "temurin", "11.0.21+9"},
+		} {
+			tc := tc
+			it("should detect jdk version "+strings.TrimSpace(tc.content)+" from "+tc.file, func() {
+				appDir, err := ioutil.TempDir("", "app")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer os.RemoveAll(appDir)
+
+				if err := ioutil.WriteFile(filepath.Join(appDir, tc.file), []byte(tc.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := installer.Init(appDir); err != nil {
+					t.Fatal(err)
+				}
+
+				if installer.Version.Vendor != tc.vendor || installer.Version.Tag != tc.tag {
+					t.Fatalf(`JDK version did not match: got %s-%s, want %s-%s`, installer.Version.Vendor, installer.Version.Tag, tc.vendor, tc.tag)
+				}
+			})
+		}
+
+		it("should prefer .java-version over .sdkmanrc and .tool-versions", func() {
+			appDir, err := ioutil.TempDir("", "app")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(appDir)
+
+			ioutil.WriteFile(filepath.Join(appDir, ".java-version"), []byte("11.0.1"), 0644)
+			ioutil.WriteFile(filepath.Join(appDir, ".sdkmanrc"), []byte("java=17.0.8-tem"), 0644)
+			ioutil.WriteFile(filepath.Join(appDir, ".tool-versions"), []byte("java temurin-21.0.1+12"), 0644)
+
+			if err := installer.Init(appDir); err != nil {
+				t.Fatal(err)
+			}
+
+			if installer.Version.Tag != "11.0.1" {
+				t.Fatalf(`JDK version did not match: got %s, want %s`, installer.Version.Tag, "11.0.1")
+			}
+		})
+
+		it("should reject unsupported sdkman vendors", func() {
+			appDir, err := ioutil.TempDir("", "app")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(appDir)
+
+			ioutil.WriteFile(filepath.Join(appDir, ".sdkmanrc"), []byte("java=17.0.8-ms"), 0644)
+
+			if err := installer.Init(appDir); err == nil {
+				t.Fatal("unexpected success")
+			}
+		})
+
+		for _, tc := range []struct {
+			file    string
+			content string
+		}{
+			{".sdkmanrc", "java=17.0.8-tem\n"},
+			{".tool-versions", "java temurin-17.0.8\n"},
+		} {
+			tc := tc
+			it("should use the latest jdk 17 for "+strings.TrimSpace(tc.content)+" from "+tc.file+" when it isn't in the catalog", func() {
+				appDir, err := ioutil.TempDir("", "app")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer os.RemoveAll(appDir)
+
+				if err := ioutil.WriteFile(filepath.Join(appDir, tc.file), []byte(tc.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+
+				out := &bytes.Buffer{}
+				installer.Out = out
+				if err := installer.Init(appDir); err != nil {
+					t.Fatal(err)
+				}
+
+				latest, err := catalog.LatestVersion("temurin", 17)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if installer.Version != latest {
+					t.Fatalf(`JDK version did not match: got %+v, want %+v`, installer.Version, latest)
+				}
+
+				if !strings.Contains(out.String(), "WARNING: JDK temurin-17.0.8 is not in the catalog, using JDK "+latest.Tag) {
+					t.Fatalf(`expected a warning about the missing release: got %s`, out)
+				}
+			})
+		}
+
+		it("should prefer system.properties over pom.xml", func() {
+			appDir, err := ioutil.TempDir("", "app")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(appDir)
+
+			ioutil.WriteFile(filepath.Join(appDir, "pom.xml"), []byte("<project><properties><java.version>17</java.version></properties></project>"), 0644)
+			ioutil.WriteFile(filepath.Join(appDir, "system.properties"), []byte("java.runtime.version=11"), 0644)
+
+			if err := installer.Init(appDir); err != nil {
+				t.Fatal(err)
+			}
+
+			if installer.Version.Major != 11 {
+				t.Fatalf(`JDK version did not match: got %d, want %d`, installer.Version.Major, 11)
+			}
+		})
 	})
 
 	when("#GetVersionUrl", func() {
 		it("should get 10.0.2", func() {
-			url, err := jdk.GetVersionUrl(jdk.Version{
+			url, err := catalog.GetVersionUrl(jdk.Version{
 				Major:  10,
 				Tag:    "10.0.2",
 				Vendor: "openjdk",
@@ -134,7 +345,7 @@ func testJdkInstaller(t *testing.T, when spec.G, it spec.S) {
 		})
 
 		it("should get 1.8.0_181", func() {
-			url, err := jdk.GetVersionUrl(jdk.Version{
+			url, err := catalog.GetVersionUrl(jdk.Version{
 				Major:  8,
 				Tag:    "1.8.0_181",
 				Vendor: "openjdk",
@@ -149,7 +360,7 @@ func testJdkInstaller(t *testing.T, when spec.G, it spec.S) {
 		})
 
 		it("should get zulu-1.8.0_181", func() {
-			url, err := jdk.GetVersionUrl(jdk.Version{
+			url, err := catalog.GetVersionUrl(jdk.Version{
 				Major:  8,
 				Tag:    "1.8.0_181",
 				Vendor: "zulu",
@@ -167,7 +378,7 @@ func testJdkInstaller(t *testing.T, when spec.G, it spec.S) {
 	when("#ParseVersionString", func() {
 		it("should parse 10.0.2", func() {
 			expected := "10.0.2"
-			v, err := jdk.ParseVersionString(expected)
+			v, err := catalog.ParseVersionString(expected)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -187,7 +398,7 @@ func testJdkInstaller(t *testing.T, when spec.G, it spec.S) {
 
 		it("should parse 1.8", func() {
 			expected := "1.8"
-			v, err := jdk.ParseVersionString(expected)
+			v, err := catalog.ParseVersionString(expected)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -196,8 +407,9 @@ func testJdkInstaller(t *testing.T, when spec.G, it spec.S) {
 				t.Fatalf(`JDK version did not match: got %d, want %d`, v.Major, 8)
 			}
 
-			if v.Tag != jdk.DefaultVersionStrings[8] {
-				t.Fatalf(`JDK version did not match: got %s, want %s`, v.Tag, jdk.DefaultVersionStrings[8])
+			latest, _ := catalog.LatestVersion("openjdk", 8)
+			if v.Tag != latest.Tag {
+				t.Fatalf(`JDK version did not match: got %s, want %s`, v.Tag, latest.Tag)
 			}
 
 			if v.Vendor != "openjdk" {
@@ -207,7 +419,7 @@ func testJdkInstaller(t *testing.T, when spec.G, it spec.S) {
 
 		it("should parse 11", func() {
 			expected := "11"
-			v, err := jdk.ParseVersionString(expected)
+			v, err := catalog.ParseVersionString(expected)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -216,8 +428,9 @@ func testJdkInstaller(t *testing.T, when spec.G, it spec.S) {
 				t.Fatalf(`JDK version did not match: got %d, want %d`, v.Major, 11)
 			}
 
-			if v.Tag != jdk.DefaultVersionStrings[11] {
-				t.Fatalf(`JDK version did not match: got %s, want %s`, v.Tag, jdk.DefaultVersionStrings[11])
+			latest, _ := catalog.LatestVersion("openjdk", 11)
+			if v.Tag != latest.Tag {
+				t.Fatalf(`JDK version did not match: got %s, want %s`, v.Tag, latest.Tag)
 			}
 
 			if v.Vendor != "openjdk" {
@@ -227,7 +440,7 @@ func testJdkInstaller(t *testing.T, when spec.G, it spec.S) {
 
 		it("should parse 9", func() {
 			expected := "9+181"
-			v, err := jdk.ParseVersionString(expected)
+			v, err := catalog.ParseVersionString(expected)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -247,7 +460,7 @@ func testJdkInstaller(t *testing.T, when spec.G, it spec.S) {
 
 		it("should parse zulu", func() {
 			expected := "zulu-1.8.0_191"
-			v, err := jdk.ParseVersionString(expected)
+			v, err := catalog.ParseVersionString(expected)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -267,7 +480,7 @@ func testJdkInstaller(t *testing.T, when spec.G, it spec.S) {
 
 		it("should parse openjdk", func() {
 			expected := "openjdk-1.8.0_191"
-			v, err := jdk.ParseVersionString(expected)
+			v, err := catalog.ParseVersionString(expected)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -285,9 +498,34 @@ func testJdkInstaller(t *testing.T, when spec.G, it spec.S) {
 			}
 		})
 
+		it("should parse 17", func() {
+			v, err := catalog.ParseVersionString("17")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			latest, _ := catalog.LatestVersion("openjdk", 17)
+			if v.Major != 17 || v.Tag != latest.Tag {
+				t.Fatalf(`JDK version did not match: got %d %s, want %d %s`, v.Major, v.Tag, 17, latest.Tag)
+			}
+		})
+
+		it("should parse any later major version", func() {
+			for s, expected := range map[string]int{"21.0.1+12": 21, "zulu-30.0.1": 30} {
+				v, err := catalog.ParseVersionString(s)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if v.Major != expected {
+					t.Fatalf(`JDK version did not match: got %d, want %d`, v.Major, expected)
+				}
+			}
+		})
+
 		it("should not parse garbage", func() {
 			expected := "1bh"
-			_, err := jdk.ParseVersionString(expected)
+			_, err := catalog.ParseVersionString(expected)
 			if err == nil {
 				t.Fatal("unexpected success")
 			}
@@ -299,3 +537,61 @@ func fixture(name string) string {
 	wd, _ := os.Getwd()
 	return filepath.Join(wd, "..", "test", "fixtures", name)
 }
+
+// startMirror serves JDKs at DEFAULT_JDK_BASE_URL for heroku-18 with whichever
+// handler the test last set.
+func startMirror(handler *http.HandlerFunc) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		(*handler)(w, r)
+	}))
+
+	os.Setenv("STACK", "heroku-18")
+	os.Setenv("DEFAULT_JDK_BASE_URL", server.URL)
+	return server
+}
+
+func stopMirror(server *httptest.Server) {
+	server.Close()
+	os.Unsetenv("DEFAULT_JDK_BASE_URL")
+}
+
+// countDownloads counts the JDK tarballs the handler serves.
+func countDownloads(downloads *int, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, ".tar.gz") {
+			*downloads++
+		}
+		handler(w, r)
+	}
+}
+
+func newInstaller(out io.Writer) *jdk.Installer {
+	wd, _ := os.Getwd()
+	return &jdk.Installer{
+		In:           []byte{},
+		Out:          out,
+		Err:          ioutil.Discard,
+		BuildpackDir: filepath.Join(wd, ".."),
+	}
+}
+
+func tempLayers(t *testing.T) layers.Layers {
+	root, err := ioutil.TempDir("", "layers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return layers.NewLayers(root, logger.DefaultLogger())
+}
+
+func layerToml(t *testing.T, layersDir layers.Layers, name string) string {
+	toml, err := ioutil.ReadFile(layersDir.Layer(name).Metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(toml)
+}
+
+func layerFileExists(layersDir layers.Layers, layer string, path ...string) bool {
+	_, err := os.Lstat(filepath.Join(append([]string{layersDir.Layer(layer).Root}, path...)...))
+	return err == nil
+}
@@ -0,0 +1,265 @@
+package jdk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/heroku/java-buildpack/util"
+)
+
+const (
+	JlinkProperty   = "java.runtime.jlink"
+	ModulesProperty = "java.runtime.modules"
+)
+
+var (
+	moduleListPattern = regexp.MustCompile(`^[A-Za-z0-9_.]+(,[A-Za-z0-9_.]+)*$`)
+)
+
+// Linker replaces the JRE launch layer with a runtime image built by jlink
+// that contains only the modules the app needs.
+type Linker struct {
+	In           []byte
+	Out, Err     io.Writer
+	BuildpackDir string
+}
+
+// Link runs when java.runtime.jlink=true is set in system.properties. The
+// modules are found by running jdeps on the app's executable jar, plus any
+// listed in java.runtime.modules, which are used on their own when jdeps
+// can't analyze the app. The installer leaves the JRE layer to the linker
+// then, so a runtime linked with the same modules is reused, and the JRE is
+// installed when the runtime can't be linked. It returns false if it wasn't.
+func (l *Linker) Link(appDir string, layersDir layers.Layers) (Jdk, bool, error) {
+	sysProps, err := util.ReadPropertiesFile(filepath.Join(appDir, "system.properties"))
+	if err != nil {
+		return Jdk{}, false, nil
+	}
+
+	if enabled, _ := strconv.ParseBool(sysProps[JlinkProperty]); !enabled {
+		return Jdk{}, false, nil
+	}
+
+	if RuntimeJdk() {
+		fmt.Fprintf(l.Out, "Skipping jlink, the app launches with the JDK because JAVA_RUNTIME_JDK is set\n")
+		return Jdk{}, false, nil
+	}
+
+	var jdk Jdk
+	if err := layersDir.Layer("jdk").ReadMetadata(&jdk); err != nil {
+		return Jdk{}, false, err
+	} else if jdk.Home == "" {
+		return Jdk{}, false, failedToLinkRuntime(errors.New(fmt.Sprintf("no JDK installed in %s", layersDir.Layer("jdk").Root)))
+	}
+
+	layer := layersDir.Layer(JreLayerName)
+	overlayDir := filepath.Join(appDir, JdkOverlayDir)
+
+	if jdk.Version.Major < 9 {
+		fmt.Fprintf(l.Out, "Skipping jlink, it requires JDK 9 or later\n")
+		return l.installJre(jdk, layer, overlayDir)
+	}
+
+	jmods := filepath.Join(jdk.Home, "jmods")
+	if _, err := os.Stat(jmods); err != nil {
+		fmt.Fprintf(l.Out, "Skipping jlink, JDK %s does not include jmods\n", jdk.Version.Tag)
+		return l.installJre(jdk, layer, overlayDir)
+	}
+
+	jar, err := util.FindExecutableJarPath(appDir)
+	if err != nil {
+		fmt.Fprintf(l.Out, "Skipping jlink, no executable jar found in target\n")
+		return l.installJre(jdk, layer, overlayDir)
+	}
+
+	explicit := splitModules(sysProps[ModulesProperty])
+	modules, err := l.analyzeModules(jdk, jar, appDir)
+	if err != nil && len(explicit) == 0 {
+		fmt.Fprintf(l.Out, "Skipping jlink, jdeps could not analyze %s. List the app's modules in %s in system.properties to link it anyway.\n", filepath.Base(jar), ModulesProperty)
+		return l.installJre(jdk, layer, overlayDir)
+	} else if err != nil {
+		fmt.Fprintf(l.Out, "jdeps could not analyze %s, linking the modules in %s\n", filepath.Base(jar), ModulesProperty)
+	}
+	modules = mergeModules(modules, explicit)
+
+	runtime := Jdk{
+		Version:     jdk.Version,
+		Home:        layer.Root,
+		Url:         jdk.Url,
+		Sha256:      jdk.Sha256,
+		Modules:     strings.Join(modules, ","),
+		Certs:       jdk.Certs,
+		Overlay:     jdk.Overlay,
+		Security:    jdk.Security,
+		Stack:       jdk.Stack,
+		InstalledAt: time.Now().UTC().Truncate(time.Second),
+	}
+
+	var cached Jdk
+	if err := layer.ReadMetadata(&cached); err != nil {
+		return runtime, false, err
+	}
+
+	if runtime.IsReusable(cached) {
+		fmt.Fprintf(l.Out, "Using cached runtime with modules %s\n", runtime.Modules)
+		runtime.InstalledAt = cached.InstalledAt
+		return runtime, true, l.writeLaunchLayer(runtime, layer)
+	}
+
+	if err := l.jlink(jdk, jmods, modules, layer.Root); err != nil {
+		fmt.Fprintf(l.Out, "WARNING: jlink could not link a runtime with modules %s, using the JRE instead: %s\n", runtime.Modules, err)
+		return l.installJre(jdk, layer, overlayDir)
+	}
+
+	// the JDK's install already logged the overlay's files
+	if err := applyOverlay(ioutil.Discard, layer.Root, overlayDir, jdk.Overlay); err != nil {
+		return runtime, false, err
+	}
+
+	// use the JDK's truststore, which has the app's certificates
+	runtimeCacerts := filepath.Join(runtime.Home, "lib", "security", "cacerts")
+	if err := os.Remove(runtimeCacerts); err != nil && !os.IsNotExist(err) {
+		return runtime, false, err
+	}
+	if err := copyTree(filepath.Join(jdk.Home, "lib", "security", "cacerts"), runtimeCacerts, nil); err != nil && !os.IsNotExist(err) {
+		return runtime, false, err
+	}
+
+	// and its java.security, which has the app's settings
+	if len(jdk.Security) > 0 {
+		if err := os.Remove(JavaSecurityPath(runtime)); err != nil && !os.IsNotExist(err) {
+			return runtime, false, err
+		}
+		if err := copyTree(JavaSecurityPath(jdk), JavaSecurityPath(runtime), nil); err != nil {
+			return runtime, false, err
+		}
+	}
+
+	if err := l.writeLaunchLayer(runtime, layer); err != nil {
+		return runtime, false, err
+	}
+
+	fmt.Fprintf(l.Out, "Linked a runtime with modules %s\n", runtime.Modules)
+	return runtime, true, nil
+}
+
+// installJre installs the JRE the app launches with when a runtime can't be
+// linked.
+func (l *Linker) installJre(jdk Jdk, layer layers.Layer, overlayDir string) (Jdk, bool, error) {
+	installer := Installer{In: l.In, Out: l.Out, Err: l.Err, BuildpackDir: l.BuildpackDir}
+	jre, err := installer.installJre(jdk, layer, false, overlayDir)
+	if err != nil {
+		return jre, false, err
+	}
+	return jre, false, l.writeLaunchLayer(jre, layer)
+}
+
+func (l *Linker) writeLaunchLayer(runtime Jdk, layer layers.Layer) error {
+	if err := CreateProfileScripts(l.BuildpackDir, layer); err != nil {
+		return err
+	}
+
+	if err := layer.OverrideLaunchEnv("JAVA_HOME", "%s", layer.Root); err != nil {
+		return err
+	}
+	return runtime.WriteMetadata(layer, layers.Launch, layers.Cache)
+}
+
+// jlinkEnabled is whether java.runtime.jlink=true is set in system.properties.
+func jlinkEnabled(appDir string) bool {
+	sysProps, err := util.ReadPropertiesFile(filepath.Join(appDir, "system.properties"))
+	if err != nil {
+		return false
+	}
+
+	enabled, _ := strconv.ParseBool(sysProps[JlinkProperty])
+	return enabled
+}
+
+func (l *Linker) analyzeModules(jdk Jdk, jar, appDir string) ([]string, error) {
+	args := []string{"--print-module-deps", "--multi-release", strconv.Itoa(jdk.Version.Major)}
+	if jdk.Version.Major >= 12 {
+		args = append(args, "--ignore-missing-deps")
+	}
+
+	// jars copied by the maven-dependency-plugin's copy-dependencies goal
+	if deps, err := filepath.Glob(filepath.Join(appDir, "target", "dependency", "*.jar")); err == nil && len(deps) > 0 {
+		args = append(args, "--class-path", strings.Join(deps, string(filepath.ListSeparator)))
+	}
+	args = append(args, jar)
+
+	out := &bytes.Buffer{}
+	cmd := exec.Command(filepath.Join(jdk.Home, "bin", "jdeps"), args...)
+	cmd.Env = os.Environ()
+	cmd.Dir = appDir
+	cmd.Stdin = bytes.NewBuffer(l.In)
+	cmd.Stdout = out
+	cmd.Stderr = l.Err
+
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if deps := strings.TrimSpace(lines[len(lines)-1]); moduleListPattern.MatchString(deps) {
+		return splitModules(deps), nil
+	}
+	return nil, errors.New(fmt.Sprintf("unexpected jdeps output: %s", out))
+}
+
+func (l *Linker) jlink(jdk Jdk, jmods string, modules []string, output string) error {
+	// jlink refuses to write to an existing directory
+	if err := os.RemoveAll(output); err != nil {
+		return err
+	}
+
+	cmd := exec.Command(filepath.Join(jdk.Home, "bin", "jlink"),
+		"--module-path", jmods,
+		"--add-modules", strings.Join(modules, ","),
+		"--strip-debug",
+		"--no-header-files",
+		"--no-man-pages",
+		"--compress=2",
+		"--output", output)
+	cmd.Env = os.Environ()
+	cmd.Stdin = bytes.NewBuffer(l.In)
+	cmd.Stdout = l.Out
+	cmd.Stderr = l.Err
+
+	return cmd.Run()
+}
+
+func splitModules(list string) []string {
+	var modules []string
+	for _, module := range strings.Split(list, ",") {
+		if module = strings.TrimSpace(module); module != "" {
+			modules = append(modules, module)
+		}
+	}
+	return modules
+}
+
+func mergeModules(lists ...[]string) []string {
+	modules := []string{"java.base"}
+	for _, list := range lists {
+		for _, module := range list {
+			if !containsString(modules, module) {
+				modules = append(modules, module)
+			}
+		}
+	}
+	sort.Strings(modules)
+	return modules
+}
@@ -0,0 +1,267 @@
+package jdk_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/buildpack/libbuildpack/logger"
+	"github.com/heroku/java-buildpack/jdk"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestJlink(t *testing.T) {
+	spec.Run(t, "Jlink", testJlink, spec.Report(report.Terminal{}))
+}
+
+// fakeJlink creates the output image and records the modules it was asked for
+const fakeJlink = `#!/bin/sh
+while [ $# -gt 0 ]; do
+  case "$1" in
+  --output) output="$2"; shift ;;
+  --add-modules) modules="$2"; shift ;;
+  esac
+  shift
+done
+mkdir -p "$output/bin" "$output/lib/security"
+touch "$output/bin/java"
+echo "$modules" > "$output/modules"
+`
+
+func testJlink(t *testing.T, when spec.G, it spec.S) {
+	var (
+		linker    *jdk.Linker
+		layersDir layers.Layers
+		appDir    string
+	)
+
+	it.Before(func() {
+		wd, _ := os.Getwd()
+
+		linker = &jdk.Linker{
+			In:           []byte{},
+			Out:          ioutil.Discard,
+			Err:          ioutil.Discard,
+			BuildpackDir: filepath.Join(wd, ".."),
+		}
+
+		layersRoot, err := ioutil.TempDir("", "layers")
+		if err != nil {
+			t.Fatal(err)
+		}
+		layersDir = layers.NewLayers(layersRoot, logger.DefaultLogger())
+
+		appDir, err = ioutil.TempDir("", "app")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		jar, err := ioutil.ReadFile(filepath.Join(fixture("app_with_exec_jar"), "target", "my-app-1.0-SNAPSHOT-jar-with-dependencies.jar"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.MkdirAll(filepath.Join(appDir, "target"), 0755)
+		ioutil.WriteFile(filepath.Join(appDir, "target", "my-app.jar"), jar, 0644)
+	})
+
+	it.After(func() {
+		os.RemoveAll(layersDir.Root)
+		os.RemoveAll(appDir)
+	})
+
+	installJdk := func(major int, jdeps string) {
+		jdkLayer := layersDir.Layer("jdk")
+		os.MkdirAll(filepath.Join(jdkLayer.Root, "bin"), 0755)
+		os.MkdirAll(filepath.Join(jdkLayer.Root, "jmods"), 0755)
+		ioutil.WriteFile(filepath.Join(jdkLayer.Root, "bin", "jdeps"), []byte("#!/bin/sh\n"+jdeps+"\n"), 0755)
+		ioutil.WriteFile(filepath.Join(jdkLayer.Root, "bin", "jlink"), []byte(fakeJlink), 0755)
+
+		installed := jdk.Jdk{
+			Home:    jdkLayer.Root,
+			Version: jdk.Version{Major: major, Tag: "11.0.1", Vendor: "openjdk"},
+		}
+		if err := installed.WriteMetadata(jdkLayer, layers.Build, layers.Cache); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	systemProperties := func(props string) {
+		if err := ioutil.WriteFile(filepath.Join(appDir, "system.properties"), []byte(props), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	linkedModules := func() string {
+		modules, err := ioutil.ReadFile(filepath.Join(layersDir.Layer("jre").Root, "modules"))
+		if err != nil {
+			t.Fatal("runtime not linked")
+		}
+		return strings.TrimSpace(string(modules))
+	}
+
+	when("#Link", func() {
+		it("should do nothing unless enabled", func() {
+			installJdk(11, "echo java.base,java.sql")
+			systemProperties("java.runtime.version=11")
+
+			if _, linked, err := linker.Link(appDir, layersDir); err != nil || linked {
+				t.Fatalf(`unexpected link: %v`, err)
+			}
+
+			if _, err := os.Stat(layersDir.Layer("jre").Root); !os.IsNotExist(err) {
+				t.Fatal("JRE layer was changed")
+			}
+		})
+
+		it("should link the modules found by jdeps", func() {
+			installJdk(11, "echo 'Warning: ignored'\necho java.base,java.sql")
+			systemProperties("java.runtime.version=11\njava.runtime.jlink=true\njava.runtime.modules=jdk.crypto.ec")
+
+			runtime, linked, err := linker.Link(appDir, layersDir)
+			if err != nil || !linked {
+				t.Fatalf(`runtime not linked: %v`, err)
+			}
+
+			expected := "java.base,java.sql,jdk.crypto.ec"
+			if modules := linkedModules(); modules != expected {
+				t.Fatalf(`linked modules did not match: got %s, want %s`, modules, expected)
+			}
+
+			var metadata jdk.Jdk
+			if err := layersDir.Layer("jre").ReadMetadata(&metadata); err != nil || metadata.Modules != expected || metadata.Home != runtime.Home {
+				t.Fatalf(`JRE layer metadata did not match: got %+v`, metadata)
+			}
+
+			if _, err := os.Stat(filepath.Join(layersDir.Layer("jre").Root, "profile.d", "jvm.sh")); err != nil {
+				t.Fatal("JVM profile.d script not installed")
+			}
+		})
+
+		it("should keep the JDK's java.security settings", func() {
+			installJdk(11, "echo java.base")
+			systemProperties("java.runtime.jlink=true")
+
+			jdkLayer := layersDir.Layer("jdk")
+			var installed jdk.Jdk
+			jdkLayer.ReadMetadata(&installed)
+			installed.Security = map[string]string{"crypto.policy": "unlimited"}
+			installed.WriteMetadata(jdkLayer, layers.Build, layers.Cache)
+			os.MkdirAll(filepath.Join(jdkLayer.Root, "conf", "security"), 0755)
+			ioutil.WriteFile(filepath.Join(jdkLayer.Root, "conf", "security", "java.security"), []byte("crypto.policy=unlimited\n"), 0644)
+
+			if _, linked, err := linker.Link(appDir, layersDir); err != nil || !linked {
+				t.Fatalf(`runtime not linked: %v`, err)
+			}
+
+			if security, _ := ioutil.ReadFile(filepath.Join(layersDir.Layer("jre").Root, "conf", "security", "java.security")); string(security) != "crypto.policy=unlimited\n" {
+				t.Fatalf(`java.security not copied to the runtime: got %s`, security)
+			}
+		})
+
+		it("should fall back to the explicit modules when jdeps fails", func() {
+			installJdk(11, "echo 'Error: missing dependencies' >&2\nexit 1")
+			systemProperties("java.runtime.jlink=true\njava.runtime.modules=java.naming, java.sql")
+
+			if _, linked, err := linker.Link(appDir, layersDir); err != nil || !linked {
+				t.Fatalf(`runtime not linked: %v`, err)
+			}
+
+			expected := "java.base,java.naming,java.sql"
+			if modules := linkedModules(); modules != expected {
+				t.Fatalf(`linked modules did not match: got %s, want %s`, modules, expected)
+			}
+		})
+
+		it("should keep the JRE when jdeps fails without explicit modules", func() {
+			installJdk(11, "exit 1")
+			systemProperties("java.runtime.jlink=true")
+
+			if _, linked, err := linker.Link(appDir, layersDir); err != nil || linked {
+				t.Fatalf(`unexpected link: %v`, err)
+			}
+
+			if jreToml, err := ioutil.ReadFile(layersDir.Layer("jre").Metadata); err != nil || !strings.Contains(string(jreToml), "launch = true") {
+				t.Fatalf(`JRE not installed for launch: %v`, err)
+			}
+
+			if _, err := os.Stat(filepath.Join(layersDir.Layer("jre").Root, "profile.d", "jvm.sh")); err != nil {
+				t.Fatal("JVM profile.d script not installed")
+			}
+		})
+
+		it("should keep the JRE when jlink fails", func() {
+			installJdk(11, "echo java.base")
+			ioutil.WriteFile(filepath.Join(layersDir.Layer("jdk").Root, "bin", "jlink"), []byte("#!/bin/sh\nexit 1\n"), 0755)
+			systemProperties("java.runtime.jlink=true")
+
+			if _, linked, err := linker.Link(appDir, layersDir); err != nil || linked {
+				t.Fatalf(`unexpected link: %v`, err)
+			}
+
+			if jreToml, err := ioutil.ReadFile(layersDir.Layer("jre").Metadata); err != nil || !strings.Contains(string(jreToml), "launch = true") {
+				t.Fatalf(`JRE not installed for launch: %v`, err)
+			}
+		})
+
+		it("should reuse a runtime linked with the same modules", func() {
+			installJdk(11, "echo java.base,java.sql")
+			systemProperties("java.runtime.jlink=true")
+
+			if _, linked, err := linker.Link(appDir, layersDir); err != nil || !linked {
+				t.Fatalf(`runtime not linked: %v`, err)
+			}
+
+			// jlink fails if it is run again
+			os.Remove(filepath.Join(layersDir.Layer("jdk").Root, "bin", "jlink"))
+
+			if _, linked, err := linker.Link(appDir, layersDir); err != nil || !linked {
+				t.Fatalf(`linked runtime not reused: %v`, err)
+			}
+
+			if modules := linkedModules(); modules != "java.base,java.sql" {
+				t.Fatalf(`linked modules did not match: got %s`, modules)
+			}
+		})
+
+		it("should apply the JDK overlay to the linked runtime", func() {
+			installJdk(11, "echo java.base")
+			systemProperties("java.runtime.jlink=true")
+
+			overlayDir := filepath.Join(appDir, jdk.JdkOverlayDir)
+			os.MkdirAll(filepath.Join(overlayDir, "lib"), 0755)
+			ioutil.WriteFile(filepath.Join(overlayDir, "lib", "agent.jar"), []byte("agent"), 0644)
+
+			overlay, err := jdk.ReadOverlay(overlayDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			jdkLayer := layersDir.Layer("jdk")
+			var installed jdk.Jdk
+			jdkLayer.ReadMetadata(&installed)
+			installed.Overlay = overlay
+			installed.WriteMetadata(jdkLayer, layers.Build, layers.Cache)
+
+			if _, linked, err := linker.Link(appDir, layersDir); err != nil || !linked {
+				t.Fatalf(`runtime not linked: %v`, err)
+			}
+
+			if agent, _ := ioutil.ReadFile(filepath.Join(layersDir.Layer("jre").Root, "lib", "agent.jar")); string(agent) != "agent" {
+				t.Fatalf(`overlay not applied to the runtime: got %s`, agent)
+			}
+		})
+
+		it("should skip JDK 8", func() {
+			installJdk(8, "echo java.base")
+			systemProperties("java.runtime.jlink=true")
+
+			if _, linked, err := linker.Link(appDir, layersDir); err != nil || linked {
+				t.Fatalf(`unexpected link: %v`, err)
+			}
+		})
+	})
+}
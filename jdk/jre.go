@@ -0,0 +1,176 @@
+package jdk
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/heroku/java-buildpack/util"
+)
+
+const (
+	JreLayerName = "jre"
+)
+
+var (
+	// jreExcludes are the parts of a JDK 9+ home only needed to build apps
+	jreExcludes = []string{"demo", "include", "jmods", "man", "sample", "src.zip", "lib/ct.sym", "lib/src.zip"}
+
+	// jreTools are the commands in a JDK's bin kept in the launch layer
+	jreTools = []string{"java", "keytool", "rmiregistry"}
+
+	// layerDirs are kept in a layer by libbuildpack rather than being part of
+	// the JDK, and each layer writes its own
+	layerDirs = []string{"env", "env.build", "env.launch", "profile.d"}
+)
+
+// RuntimeJdk is whether the app launches with the full JDK instead of a JRE,
+// for apps that need tools like jcmd or jstack at runtime.
+func RuntimeJdk() bool {
+	runtimeJdk, _ := strconv.ParseBool(os.Getenv("JAVA_RUNTIME_JDK"))
+	return runtimeJdk
+}
+
+// installJre creates the launch layer from an installed JDK. JDK 8 ships a JRE
+// in jre/, later JDKs are copied without the files only needed to build. The
+// app's overlay is applied to the JRE too, as the JRE is what it launches with.
+func (i *Installer) installJre(jdk Jdk, layer layers.Layer, reinstall bool, overlayDir string) (Jdk, error) {
+	jre := Jdk{
+		Version:     jdk.Version,
+		Home:        layer.Root,
+		Url:         jdk.Url,
+		Sha256:      jdk.Sha256,
+		Certs:       jdk.Certs,
+		Overlay:     jdk.Overlay,
+		Stack:       jdk.Stack,
+		InstalledAt: jdk.InstalledAt,
+	}
+
+	var cached Jdk
+	if err := layer.ReadMetadata(&cached); err != nil {
+		return jre, err
+	}
+
+	if !reinstall && jre.IsReusable(cached) {
+		return jre, nil
+	}
+
+	if err := util.ResetDir(layer.Root); err != nil {
+		return jre, err
+	}
+
+	overlay := jdk.Overlay
+	bundledJre := filepath.Join(jdk.Home, "jre")
+	if _, err := os.Stat(filepath.Join(bundledJre, "bin", "java")); err == nil {
+		if err := copyTree(bundledJre, layer.Root, nil); err != nil {
+			return jre, err
+		}
+
+		// profile.d/jvm.sh reads the version from the release file
+		if err := copyTree(filepath.Join(jdk.Home, "release"), filepath.Join(layer.Root, "release"), nil); err != nil && !os.IsNotExist(err) {
+			return jre, err
+		}
+		overlay = jreOverlay(overlay)
+	} else if err := copyTree(jdk.Home, layer.Root, isJreFile); err != nil {
+		return jre, err
+	}
+
+	// the JDK's install already logged the overlay's files
+	if err := applyOverlay(ioutil.Discard, layer.Root, overlayDir, overlay); err != nil {
+		return jre, err
+	}
+
+	fmt.Fprintf(i.Out, "Installed JRE %s for launch\n", jre.Version.Tag)
+	return jre, nil
+}
+
+func removeLayer(layer layers.Layer) error {
+	if err := os.RemoveAll(layer.Root); err != nil {
+		return err
+	}
+	return layer.RemoveMetadata()
+}
+
+func isJreFile(rel string, info os.FileInfo) bool {
+	if containsString(jreExcludes, rel) || containsString(layerDirs, rel) {
+		return false
+	}
+
+	if filepath.Dir(rel) == "bin" && !info.IsDir() {
+		return containsString(jreTools, filepath.Base(rel))
+	}
+	return true
+}
+
+// copyTree copies a file or directory, keeping permissions and symlinks.
+// Relative links pointing outside of src are replaced by a copy of their target.
+func copyTree(src, dest string, include func(rel string, info os.FileInfo) bool) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		if rel != "." && include != nil && !include(filepath.ToSlash(rel), info) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		target := filepath.Join(dest, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			// absolute links, like cacerts to the system store, are kept as is
+			resolved := filepath.Join(filepath.Dir(path), link)
+			if filepath.IsAbs(link) || strings.HasPrefix(resolved, filepath.Clean(src)+string(filepath.Separator)) {
+				return os.Symlink(link, target)
+			}
+
+			if info, err = os.Stat(resolved); err != nil || info.IsDir() {
+				// a dangling or directory link outside of src can't be kept
+				return nil
+			}
+			return copyFile(resolved, target, info.Mode().Perm())
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(src, dest string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
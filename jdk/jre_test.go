@@ -0,0 +1,214 @@
+package jdk_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/heroku/java-buildpack/jdk"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestJre(t *testing.T) {
+	spec.Run(t, "Jre", testJre, spec.Report(report.Terminal{}))
+}
+
+func testJre(t *testing.T, when spec.G, it spec.S) {
+	var (
+		installer *jdk.Installer
+		layersDir layers.Layers
+		server    *httptest.Server
+		handler   http.HandlerFunc
+	)
+
+	it.Before(func() {
+		server = startMirror(&handler)
+		installer = newInstaller(ioutil.Discard)
+		layersDir = tempLayers(t)
+	})
+
+	it.After(func() {
+		stopMirror(server)
+		os.Unsetenv("JAVA_RUNTIME_JDK")
+		os.RemoveAll(layersDir.Root)
+	})
+
+	when("#Install", func() {
+		it("should launch with a trimmed JDK", func() {
+			handler = serveTarball(tarball(
+				tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "bin/javac", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "bin/jcmd", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "jmods/java.base.jmod", Mode: 0644, Body: "jmod"},
+				tarEntry{Name: "lib/modules", Mode: 0644, Body: "modules"},
+				tarEntry{Name: "lib/src.zip", Mode: 0644, Body: "src"},
+				tarEntry{Name: "release", Mode: 0644, Body: `JAVA_VERSION="11.0.1"`},
+			))
+
+			if _, err := installer.Install(fixture("app_with_jdk_11"), layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, path := range []string{"bin/java", "lib/modules", "release", "profile.d/jvm.sh"} {
+				if !layerFileExists(layersDir, "jre", path) {
+					t.Fatalf(`%s not installed in the JRE`, path)
+				}
+			}
+
+			for _, path := range []string{"bin/javac", "bin/jcmd", "jmods", "lib/src.zip"} {
+				if layerFileExists(layersDir, "jre", path) {
+					t.Fatalf(`%s installed in the JRE`, path)
+				}
+			}
+
+			if !layerFileExists(layersDir, "jdk", "bin", "javac") {
+				t.Fatal("javac not installed in the JDK")
+			}
+
+			if jdkToml := layerToml(t, layersDir, "jdk"); !strings.Contains(jdkToml, "build = true") || !strings.Contains(jdkToml, "launch = false") {
+				t.Fatalf(`JDK layer is not build only: \n%s`, jdkToml)
+			}
+
+			if jreToml := layerToml(t, layersDir, "jre"); !strings.Contains(jreToml, "launch = true") || !strings.Contains(jreToml, "build = false") {
+				t.Fatalf(`JRE layer is not launch only: \n%s`, jreToml)
+			}
+		})
+
+		it("should launch with the JRE bundled in JDK 8", func() {
+			handler = serveTarball(tarball(
+				tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "bin/javac", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "jre/bin/java", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "jre/lib/rt.jar", Mode: 0644, Body: "rt"},
+				tarEntry{Name: "release", Mode: 0644, Body: `JAVA_VERSION="1.8.0_191"`},
+			))
+
+			if _, err := installer.Install(fixture("app_with_pom"), layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, path := range []string{"bin/java", "lib/rt.jar", "release"} {
+				if !layerFileExists(layersDir, "jre", path) {
+					t.Fatalf(`%s not installed in the JRE`, path)
+				}
+			}
+
+			if layerFileExists(layersDir, "jre", "bin", "javac") {
+				t.Fatal("javac installed in the JRE")
+			}
+
+			info, err := os.Stat(filepath.Join(layersDir.Layer("jre").Root, "bin", "java"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if info.Mode().Perm() != 0755 {
+				t.Fatalf(`java is not executable: %v`, info.Mode())
+			}
+		})
+
+		it("should not copy the JDK layer's environment into the JRE", func() {
+			handler = serveTarball(tarball(
+				tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "release", Mode: 0644, Body: `JAVA_VERSION="11.0.1"`},
+			))
+
+			os.Setenv("JAVA_RUNTIME_JDK", "true")
+			if _, err := installer.Install(fixture("app_with_jdk_11"), layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			layerDirs := []string{"env", "env.build", "env.launch", "profile.d"}
+			for _, dir := range layerDirs {
+				marker := filepath.Join(layersDir.Layer("jdk").Root, dir, "MARKER")
+				if err := os.MkdirAll(filepath.Dir(marker), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(marker, []byte("jdk"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			os.Unsetenv("JAVA_RUNTIME_JDK")
+			if _, err := installer.Install(fixture("app_with_jdk_11"), layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, dir := range layerDirs {
+				if layerFileExists(layersDir, "jre", dir, "MARKER") {
+					t.Fatalf(`%s copied from the JDK layer`, dir)
+				}
+			}
+		})
+
+		it("should leave the JRE to the linker when jlink is enabled", func() {
+			handler = serveTarball(tarball(
+				tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "release", Mode: 0644, Body: `JAVA_VERSION="11.0.1"`},
+			))
+
+			appDir, err := ioutil.TempDir("", "app")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(appDir)
+			if err := ioutil.WriteFile(filepath.Join(appDir, "system.properties"), []byte("java.runtime.version=11\njava.runtime.jlink=true"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := installer.Install(appDir, layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			if layerFileExists(layersDir, "jre", "bin", "java") {
+				t.Fatal("JRE installed before linking")
+			}
+
+			if jdkToml := layerToml(t, layersDir, "jdk"); strings.Contains(jdkToml, "launch = true") {
+				t.Fatalf(`JDK layer is launched: \n%s`, jdkToml)
+			}
+		})
+
+		it("should launch with the JDK when JAVA_RUNTIME_JDK is set", func() {
+			handler = serveTarball(tarball(
+				tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "bin/jcmd", Mode: 0755, Body: "#!/bin/sh"},
+			))
+
+			if _, err := installer.Install(fixture("app_with_jdk_11"), layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			if !layerFileExists(layersDir, "jre") {
+				t.Fatal("JRE layer not created")
+			}
+
+			os.Setenv("JAVA_RUNTIME_JDK", "true")
+			if _, err := installer.Install(fixture("app_with_jdk_11"), layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			if layerFileExists(layersDir, "jre") {
+				t.Fatal("JRE layer not removed")
+			}
+
+			if _, err := os.Stat(layersDir.Layer("jre").Metadata); !os.IsNotExist(err) {
+				t.Fatal("JRE layer metadata not removed")
+			}
+
+			if !layerFileExists(layersDir, "jdk", "profile.d", "jvm.sh") {
+				t.Fatal("JVM profile.d script not installed in the JDK")
+			}
+
+			if jdkToml := layerToml(t, layersDir, "jdk"); !strings.Contains(jdkToml, "launch = true") {
+				t.Fatalf(`JDK layer is not launched: \n%s`, jdkToml)
+			}
+		})
+	})
+}
@@ -0,0 +1,200 @@
+package jdk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/heroku/java-buildpack/util"
+)
+
+const (
+	JdkOverlayDir = ".jdk-overlay"
+)
+
+// OverlayFile is an entry of the app's .jdk-overlay. The overlay's entries are
+// recorded in the JDK layer metadata, so changing the overlay reinstalls the
+// JDK.
+type OverlayFile struct {
+	Path   string `toml:"path"`
+	Mode   uint32 `toml:"mode"`
+	Sha256 string `toml:"sha256,omitempty"`
+	Link   string `toml:"link,omitempty"`
+}
+
+func (f OverlayFile) isDir() bool {
+	return os.FileMode(f.Mode)&os.ModeDir != 0
+}
+
+func (f OverlayFile) isSymlink() bool {
+	return os.FileMode(f.Mode)&os.ModeSymlink != 0
+}
+
+// ReadOverlay lists the directories, files and symlinks of an overlay, parents
+// before their contents. An overlay that doesn't exist has no entries.
+func ReadOverlay(overlayDir string) ([]OverlayFile, error) {
+	if _, err := os.Stat(overlayDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var overlay []OverlayFile
+	err := filepath.Walk(overlayDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(overlayDir, file)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		entry := OverlayFile{Path: rel, Mode: uint32(info.Mode() & (os.ModeType | os.ModePerm))}
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(file)
+			if err != nil {
+				return err
+			}
+
+			// links are resolved in the JDK, so they must stay inside it
+			target := path.Join(path.Dir(rel), filepath.ToSlash(link))
+			if filepath.IsAbs(link) || target == ".." || strings.HasPrefix(target, "../") {
+				return invalidOverlay(rel, errors.New(fmt.Sprintf("symlink to %s points outside the JDK", link)))
+			}
+			entry.Link = link
+		case info.Mode().IsRegular():
+			sum, err := fileSha256(file)
+			if err != nil {
+				return err
+			}
+			entry.Sha256 = sum
+		case !info.IsDir():
+			return invalidOverlay(rel, errors.New("not a regular file, directory or symlink"))
+		}
+
+		overlay = append(overlay, entry)
+		return nil
+	})
+	return overlay, err
+}
+
+// applyJdkOverlay copies the overlay's entries into the JDK, replacing any
+// files already there. Existing files are removed rather than written to, so
+// a symlink such as the cacerts linked to the system store is replaced instead
+// of followed.
+func (i *Installer) applyJdkOverlay(home, overlayDir string, overlay []OverlayFile) error {
+	return applyOverlay(i.Out, home, overlayDir, overlay)
+}
+
+// jreOverlay is the part of the overlay applied to a JRE copied from the jre/
+// of a JDK 8, which already has the overlay's jre/ entries.
+func jreOverlay(overlay []OverlayFile) []OverlayFile {
+	var entries []OverlayFile
+	for _, f := range overlay {
+		if f.Path != "jre" && !strings.HasPrefix(f.Path, "jre/") {
+			entries = append(entries, f)
+		}
+	}
+	return entries
+}
+
+func applyOverlay(out io.Writer, home, overlayDir string, overlay []OverlayFile) error {
+	root, err := filepath.EvalSymlinks(home)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range overlay {
+		target, err := util.SecurePath(home, filepath.FromSlash(f.Path))
+		if err != nil {
+			return invalidOverlay(f.Path, err)
+		}
+
+		// a symlinked directory in the JDK could lead the copy outside of it
+		parent, err := filepath.EvalSymlinks(filepath.Dir(target))
+		if err == nil && parent != root && !strings.HasPrefix(parent, root+string(os.PathSeparator)) {
+			return invalidOverlay(f.Path, errors.New(fmt.Sprintf("%s is a symlink outside the JDK", filepath.Dir(f.Path))))
+		} else if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		existing, err := os.Lstat(target)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		replaced := err == nil
+
+		mode := os.FileMode(f.Mode) & os.ModePerm
+		if f.isDir() && replaced && existing.IsDir() {
+			if err := os.Chmod(target, mode); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if replaced {
+			if err := os.RemoveAll(target); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case f.isDir():
+			err = os.MkdirAll(target, mode)
+		case f.isSymlink():
+			err = util.ReplaceWithSymlink(f.Link, target)
+		default:
+			err = copyFile(filepath.Join(overlayDir, filepath.FromSlash(f.Path)), target, mode)
+		}
+		if err == nil && !f.isSymlink() {
+			// the umask may have masked the overlay's permissions
+			err = os.Chmod(target, mode)
+		}
+		if err != nil {
+			return invalidOverlay(f.Path, err)
+		}
+
+		if f.isDir() {
+			continue
+		} else if replaced {
+			fmt.Fprintf(out, "Replaced %s from %s\n", f.Path, JdkOverlayDir)
+		} else {
+			fmt.Fprintf(out, "Added %s from %s\n", f.Path, JdkOverlayDir)
+		}
+	}
+	return nil
+}
+
+func sameOverlay(a, b []OverlayFile) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for n := range a {
+		if a[n] != b[n] {
+			return false
+		}
+	}
+	return true
+}
+
+func fileSha256(file string) (string, error) {
+	in, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	digest := sha256.New()
+	if _, err := io.Copy(digest, in); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
@@ -0,0 +1,202 @@
+package jdk_test
+
+import (
+	"archive/tar"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/heroku/java-buildpack/jdk"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestOverlay(t *testing.T) {
+	spec.Run(t, "Overlay", testOverlay, spec.Report(report.Terminal{}))
+}
+
+func testOverlay(t *testing.T, when spec.G, it spec.S) {
+	var (
+		installer  *jdk.Installer
+		layersDir  layers.Layers
+		server     *httptest.Server
+		handler    http.HandlerFunc
+		appDir     string
+		overlayDir string
+		downloads  int
+		out        *strings.Builder
+	)
+
+	it.Before(func() {
+		downloads = 0
+		handler = countDownloads(&downloads, serveTarball(tarball(
+			tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"},
+			tarEntry{Name: "lib/security/java.security", Mode: 0644, Body: "jdk"},
+			tarEntry{Name: "lib/security/cacerts", Type: tar.TypeSymlink, Link: "../../release"},
+			tarEntry{Name: "release", Mode: 0644, Body: `JAVA_VERSION="1.8.0_191"`},
+		)))
+		server = startMirror(&handler)
+
+		out = &strings.Builder{}
+		installer = newInstaller(out)
+		layersDir = tempLayers(t)
+
+		var err error
+		appDir, err = ioutil.TempDir("", "app")
+		if err != nil {
+			t.Fatal(err)
+		}
+		overlayDir = filepath.Join(appDir, ".jdk-overlay")
+	})
+
+	it.After(func() {
+		stopMirror(server)
+		os.RemoveAll(layersDir.Root)
+		os.RemoveAll(appDir)
+	})
+
+	writeOverlay := func(name, body string, mode os.FileMode) {
+		path := filepath.Join(overlayDir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(body), mode); err != nil {
+			t.Fatal(err)
+		}
+		os.Chmod(path, mode)
+	}
+
+	when("#Install", func() {
+		it("should add and replace files in the JDK", func() {
+			writeOverlay("lib/security/java.security", "overlay", 0644)
+			writeOverlay("bin/agent", "#!/bin/sh", 0755)
+			os.Symlink("agent", filepath.Join(overlayDir, "bin", "agent-link"))
+
+			installed, err := installer.Install(appDir, layersDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if security, _ := ioutil.ReadFile(filepath.Join(installed.Home, "lib", "security", "java.security")); string(security) != "overlay" {
+				t.Fatalf(`java.security not replaced: got %s`, security)
+			}
+
+			if info, err := os.Stat(filepath.Join(installed.Home, "bin", "agent")); err != nil || info.Mode().Perm() != 0755 {
+				t.Fatalf(`bin/agent not added with its permissions: %v`, err)
+			}
+
+			if link, err := os.Readlink(filepath.Join(installed.Home, "bin", "agent-link")); err != nil || link != "agent" {
+				t.Fatalf(`bin/agent-link not added as a symlink: %v`, err)
+			}
+
+			for _, line := range []string{
+				"Replaced lib/security/java.security from .jdk-overlay",
+				"Added bin/agent from .jdk-overlay",
+				"Added bin/agent-link from .jdk-overlay",
+			} {
+				if !strings.Contains(out.String(), line) {
+					t.Fatalf(`"%s" not logged: %s`, line, out)
+				}
+			}
+
+			jreHome := layersDir.Layer(jdk.JreLayerName).Root
+			if security, _ := ioutil.ReadFile(filepath.Join(jreHome, "lib", "security", "java.security")); string(security) != "overlay" {
+				t.Fatalf(`java.security not replaced in the JRE: got %s`, security)
+			}
+
+			if info, err := os.Stat(filepath.Join(jreHome, "bin", "agent")); err != nil || info.Mode().Perm() != 0755 {
+				t.Fatalf(`bin/agent not added to the JRE with its permissions: %v`, err)
+			}
+
+			var metadata jdk.Jdk
+			if err := layersDir.Layer("jdk").ReadMetadata(&metadata); err != nil {
+				t.Fatal(err)
+			}
+			if len(metadata.Overlay) != 6 || metadata.Overlay[1].Path != "bin/agent" || metadata.Overlay[1].Sha256 == "" {
+				t.Fatalf(`overlay manifest did not match: got %+v`, metadata.Overlay)
+			}
+		})
+
+		it("should add files outside of jre/ to the JRE bundled in JDK 8", func() {
+			handler = serveTarball(tarball(
+				tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "jre/bin/java", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "jre/lib/security/cacerts", Mode: 0644, Body: "jdk cacerts"},
+				tarEntry{Name: "release", Mode: 0644, Body: `JAVA_VERSION="1.8.0_191"`},
+			))
+
+			writeOverlay("jre/lib/security/cacerts", "overlay cacerts", 0644)
+			writeOverlay("test.txt", "overlay", 0644)
+
+			if _, err := installer.Install(appDir, layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			jreHome := layersDir.Layer(jdk.JreLayerName).Root
+			if cacerts, _ := ioutil.ReadFile(filepath.Join(jreHome, "lib", "security", "cacerts")); string(cacerts) != "overlay cacerts" {
+				t.Fatalf(`cacerts not replaced in the JRE: got %s`, cacerts)
+			}
+
+			if test, _ := ioutil.ReadFile(filepath.Join(jreHome, "test.txt")); string(test) != "overlay" {
+				t.Fatalf(`test.txt not added to the JRE: got %s`, test)
+			}
+
+			if _, err := os.Stat(filepath.Join(jreHome, "jre")); !os.IsNotExist(err) {
+				t.Fatal("overlay jre/ added to the JRE")
+			}
+		})
+
+		it("should replace a symlink rather than write through it", func() {
+			writeOverlay("lib/security/cacerts", "overlay cacerts", 0644)
+
+			installed, err := installer.Install(appDir, layersDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if release, _ := ioutil.ReadFile(filepath.Join(installed.Home, "release")); string(release) != `JAVA_VERSION="1.8.0_191"` {
+				t.Fatalf(`symlink target was changed: got %s`, release)
+			}
+
+			cacerts := filepath.Join(installed.Home, "lib", "security", "cacerts")
+			if info, err := os.Lstat(cacerts); err != nil || !info.Mode().IsRegular() {
+				t.Fatalf(`cacerts symlink not replaced: %v`, err)
+			}
+
+			if body, _ := ioutil.ReadFile(cacerts); string(body) != "overlay cacerts" {
+				t.Fatalf(`cacerts did not match: got %s`, body)
+			}
+		})
+
+		it("should reject symlinks that point outside the JDK", func() {
+			os.MkdirAll(filepath.Join(overlayDir, "lib"), 0755)
+			os.Symlink("../../etc/passwd", filepath.Join(overlayDir, "lib", "passwd"))
+
+			if _, err := installer.Install(appDir, layersDir); err == nil || !strings.Contains(err.Error(), "points outside the JDK") {
+				t.Fatalf(`unexpected result: %v`, err)
+			}
+		})
+
+		it("should reinstall when the overlay changes", func() {
+			writeOverlay("lib/extra.txt", "one", 0644)
+
+			install := func(expected int) {
+				if _, err := installer.Install(appDir, layersDir); err != nil {
+					t.Fatal(err)
+				}
+
+				if downloads != expected {
+					t.Fatalf(`JDK downloads did not match: got %d, want %d`, downloads, expected)
+				}
+			}
+
+			install(1)
+			install(1)
+			writeOverlay("lib/extra.txt", "two", 0644)
+			install(2)
+		})
+	})
+}
@@ -0,0 +1,116 @@
+package jdk
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+const (
+	// pkcs12Sequence is the first byte of a PKCS12 keystore, which is a DER
+	// encoded SEQUENCE
+	pkcs12Sequence = 0x30
+)
+
+var (
+	oidData          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEncryptedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
+	oidCertBag       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidX509Cert      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+)
+
+type pfxPdu struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  asn1.RawValue `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+}
+
+type safeBag struct {
+	Id         asn1.ObjectIdentifier
+	Value      asn1.RawValue `asn1:"tag:0,explicit"`
+	Attributes asn1.RawValue `asn1:"optional"`
+}
+
+type certBag struct {
+	Id   asn1.ObjectIdentifier
+	Data []byte `asn1:"tag:0,explicit"`
+}
+
+// readPkcs12 reads the trusted certificates of a PKCS12 keystore whose
+// certificates aren't encrypted, like the cacerts of JDK 18 and later. Other
+// entries, such as private keys, are skipped.
+func readPkcs12(data []byte) ([]*x509.Certificate, error) {
+	var pfx pfxPdu
+	if err := unmarshalPkcs12(data, &pfx); err != nil {
+		return nil, err
+	}
+
+	if !pfx.AuthSafe.ContentType.Equal(oidData) {
+		return nil, errors.New("PKCS12 keystores signed with a public key are not supported")
+	}
+
+	var authSafe []byte
+	if err := unmarshalPkcs12(pfx.AuthSafe.Content.Bytes, &authSafe); err != nil {
+		return nil, err
+	}
+
+	var contents []contentInfo
+	if err := unmarshalPkcs12(authSafe, &contents); err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	for _, content := range contents {
+		switch {
+		case content.ContentType.Equal(oidEncryptedData):
+			return nil, errors.New("PKCS12 keystores with encrypted certificates are not supported")
+		case !content.ContentType.Equal(oidData):
+			return nil, errors.New(fmt.Sprintf("unknown PKCS12 content type %s", content.ContentType))
+		}
+
+		var safeContents []byte
+		if err := unmarshalPkcs12(content.Content.Bytes, &safeContents); err != nil {
+			return nil, err
+		}
+
+		var bags []safeBag
+		if err := unmarshalPkcs12(safeContents, &bags); err != nil {
+			return nil, err
+		}
+
+		for _, bag := range bags {
+			if !bag.Id.Equal(oidCertBag) {
+				continue
+			}
+
+			var cb certBag
+			if err := unmarshalPkcs12(bag.Value.Bytes, &cb); err != nil {
+				return nil, err
+			} else if !cb.Id.Equal(oidX509Cert) {
+				continue
+			}
+
+			cert, err := x509.ParseCertificate(cb.Data)
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, cert)
+		}
+	}
+	return certs, nil
+}
+
+func unmarshalPkcs12(data []byte, v interface{}) error {
+	if rest, err := asn1.Unmarshal(data, v); err != nil {
+		return errors.New(fmt.Sprintf("malformed PKCS12 keystore: %s", err))
+	} else if len(rest) > 0 {
+		return errors.New("malformed PKCS12 keystore: trailing data")
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package jdk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpack/libbuildpack/buildplan"
+)
+
+const (
+	// PlanEntryName is the build plan entry other buildpacks use to request a
+	// JDK, and that the installed JDK is contributed back to
+	PlanEntryName = "jdk"
+)
+
+// planVersion is the JDK an upstream buildpack requested in the build plan,
+// as a version string such as 11 or zulu-11. A vendor can also be given in
+// the entry's metadata.
+func planVersion(plan buildplan.BuildPlan) (string, bool) {
+	entry, ok := plan[PlanEntryName]
+	if !ok || entry.Version == "" {
+		return "", false
+	}
+
+	if vendor, ok := entry.Metadata["vendor"].(string); ok && vendor != "" && !vendorVersionPattern.MatchString(entry.Version) {
+		return fmt.Sprintf("%s-%s", vendor, entry.Version), true
+	}
+	return entry.Version, true
+}
+
+// PlanEntry describes the installed JDK to the buildpacks that run after this
+// one, so they can use it instead of installing their own.
+func (jdk Jdk) PlanEntry() buildplan.Dependency {
+	return buildplan.Dependency{
+		Version: jdk.Version.Tag,
+		Metadata: buildplan.Metadata{
+			"vendor": jdk.Version.Vendor,
+			"major":  jdk.Version.Major,
+			"home":   jdk.Home,
+		},
+	}
+}
+
+// WritePlan writes the installed JDK's entry to the build plan file the
+// lifecycle reads once the buildpack has finished.
+func WritePlan(path string, jdk Jdk) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return toml.NewEncoder(out).Encode(buildplan.BuildPlan{PlanEntryName: jdk.PlanEntry()})
+}
+
+// reconcilePlan warns when the app chooses a different JDK than an upstream
+// buildpack requested in the build plan, since the app's choice wins.
+func (i *Installer) reconcilePlan(v Version) {
+	requested, ok := planVersion(i.Plan)
+	if !ok {
+		return
+	}
+
+	if planned, err := i.Catalog.ParseVersionString(requested); err == nil && (planned.Vendor != v.Vendor || planned.Major != v.Major) {
+		fmt.Fprintf(i.Out, "WARNING: JDK %s was requested in the build plan, but the app uses JDK %s\n", requested, v.Tag)
+	}
+}
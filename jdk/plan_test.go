@@ -0,0 +1,162 @@
+package jdk_test
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpack/libbuildpack/buildplan"
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/buildpack/libbuildpack/logger"
+	"github.com/heroku/java-buildpack/jdk"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestPlan(t *testing.T) {
+	spec.Run(t, "Plan", testPlan, spec.Report(report.Terminal{}))
+}
+
+func testPlan(t *testing.T, when spec.G, it spec.S) {
+	var (
+		installer *jdk.Installer
+		appDir    string
+		out       *strings.Builder
+	)
+
+	it.Before(func() {
+		wd, _ := os.Getwd()
+		os.Setenv("STACK", "heroku-18")
+
+		out = &strings.Builder{}
+		installer = &jdk.Installer{
+			In:           []byte{},
+			Out:          out,
+			Err:          ioutil.Discard,
+			BuildpackDir: filepath.Join(wd, ".."),
+		}
+
+		var err error
+		appDir, err = ioutil.TempDir("", "app")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	it.After(func() {
+		os.RemoveAll(appDir)
+	})
+
+	when("#Init", func() {
+		it("should use the JDK requested in the build plan", func() {
+			installer.Plan = buildplan.BuildPlan{
+				jdk.PlanEntryName: buildplan.Dependency{Version: "17", Metadata: buildplan.Metadata{"vendor": "temurin"}},
+			}
+
+			if err := installer.Init(appDir); err != nil {
+				t.Fatal(err)
+			}
+
+			if installer.Version.Vendor != "temurin" || installer.Version.Major != 17 {
+				t.Fatalf(`JDK version did not match: got %+v`, installer.Version)
+			}
+
+			if !strings.Contains(out.String(), "Using JDK temurin-17 requested in the build plan") {
+				t.Fatalf(`build plan request not reported: %s`, out)
+			}
+		})
+
+		it("should prefer the app's system.properties to the build plan", func() {
+			ioutil.WriteFile(filepath.Join(appDir, "system.properties"), []byte("java.runtime.version=1.8"), 0644)
+			installer.Plan = buildplan.BuildPlan{
+				jdk.PlanEntryName: buildplan.Dependency{Version: "11"},
+			}
+
+			if err := installer.Init(appDir); err != nil {
+				t.Fatal(err)
+			}
+
+			if installer.Version.Major != 8 {
+				t.Fatalf(`JDK version did not match: got %+v`, installer.Version)
+			}
+
+			if !strings.Contains(out.String(), "WARNING: JDK 11 was requested in the build plan") {
+				t.Fatalf(`build plan conflict not reported: %s`, out)
+			}
+		})
+
+		it("should prefer the build plan to the pom.xml", func() {
+			installer.Plan = buildplan.BuildPlan{
+				jdk.PlanEntryName: buildplan.Dependency{Version: "11"},
+			}
+
+			if err := installer.Init(fixture("app_with_pom")); err != nil {
+				t.Fatal(err)
+			}
+
+			if installer.Version.Major != 11 {
+				t.Fatalf(`JDK version did not match: got %+v`, installer.Version)
+			}
+		})
+	})
+
+	when("#WritePlan", func() {
+		it("should contribute the installed JDK", func() {
+			path := filepath.Join(appDir, "plan.toml")
+			installed := jdk.Jdk{
+				Home:    "/layers/heroku_java/jdk",
+				Version: jdk.Version{Major: 11, Tag: "11.0.1", Vendor: "openjdk"},
+			}
+
+			if err := jdk.WritePlan(path, installed); err != nil {
+				t.Fatal(err)
+			}
+
+			plan := buildplan.BuildPlan{}
+			if _, err := toml.DecodeFile(path, &plan); err != nil {
+				t.Fatal(err)
+			}
+
+			entry := plan[jdk.PlanEntryName]
+			if entry.Version != "11.0.1" || entry.Metadata["vendor"] != "openjdk" || entry.Metadata["home"] != installed.Home {
+				t.Fatalf(`build plan entry did not match: got %s`, entry)
+			}
+		})
+	})
+
+	when("#Install", func() {
+		it("should set JAVA_HOME for later buildpacks and at launch", func() {
+			server := httptest.NewServer(serveTarball(tarball(
+				tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"},
+			)))
+			defer server.Close()
+			os.Setenv("DEFAULT_JDK_BASE_URL", server.URL)
+			defer os.Unsetenv("DEFAULT_JDK_BASE_URL")
+
+			layersRoot, err := ioutil.TempDir("", "layers")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(layersRoot)
+			layersDir := layers.NewLayers(layersRoot, logger.DefaultLogger())
+
+			if _, err := installer.Install(appDir, layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			jdkHome, _ := ioutil.ReadFile(filepath.Join(layersDir.Layer("jdk").Root, "env.build", "JAVA_HOME.override"))
+			if string(jdkHome) != layersDir.Layer("jdk").Root {
+				t.Fatalf(`build JAVA_HOME did not match: got %s`, jdkHome)
+			}
+
+			jreHome, _ := ioutil.ReadFile(filepath.Join(layersDir.Layer("jre").Root, "env.launch", "JAVA_HOME.override"))
+			if string(jreHome) != layersDir.Layer("jre").Root {
+				t.Fatalf(`launch JAVA_HOME did not match: got %s`, jreHome)
+			}
+		})
+	})
+}
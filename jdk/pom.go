@@ -0,0 +1,86 @@
+package jdk
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/heroku/java-buildpack/pom"
+)
+
+const (
+	compilerPluginArtifactId = "maven-compiler-plugin"
+)
+
+// pomJavaVersion is a Java version required by a pom.xml, and where in the
+// pom.xml it was found.
+type pomJavaVersion struct {
+	Version string
+	Source  string
+}
+
+// detectPomVersion infers the Java version an app compiles for from its
+// pom.xml. The compiler's release setting wins over source and target, which
+// win over the java.version property used by Spring Boot.
+func detectPomVersion(appDir string) (pomJavaVersion, bool) {
+	project, err := pom.Read(appDir)
+	if err != nil {
+		return pomJavaVersion{}, false
+	}
+
+	compilerPlugins := project.FindPlugins(compilerPluginArtifactId)
+
+	var candidates []pomJavaVersion
+	for _, plugin := range compilerPlugins {
+		candidates = append(candidates, pomJavaVersion{plugin.Configuration["release"], "maven-compiler-plugin <release>"})
+	}
+	candidates = append(candidates, pomJavaVersion{project.Property("maven.compiler.release"), "property maven.compiler.release"})
+
+	var sourceTarget []pomJavaVersion
+	for _, plugin := range compilerPlugins {
+		sourceTarget = append(sourceTarget,
+			pomJavaVersion{plugin.Configuration["target"], "maven-compiler-plugin <target>"},
+			pomJavaVersion{plugin.Configuration["source"], "maven-compiler-plugin <source>"})
+	}
+	sourceTarget = append(sourceTarget,
+		pomJavaVersion{project.Property("maven.compiler.target"), "property maven.compiler.target"},
+		pomJavaVersion{project.Property("maven.compiler.source"), "property maven.compiler.source"})
+	if newest, ok := newestPomVersion(sourceTarget); ok {
+		candidates = append(candidates, newest)
+	}
+
+	candidates = append(candidates, pomJavaVersion{project.Property("java.version"), "property java.version"})
+
+	for _, candidate := range candidates {
+		if pomMajorVersion(candidate.Version) > 0 {
+			return candidate, true
+		}
+	}
+	return pomJavaVersion{}, false
+}
+
+// newestPomVersion picks the highest of the source and target versions, since
+// the JDK must be able to compile for both.
+func newestPomVersion(candidates []pomJavaVersion) (pomJavaVersion, bool) {
+	var (
+		newest      pomJavaVersion
+		newestMajor int
+	)
+	for _, candidate := range candidates {
+		if major := pomMajorVersion(candidate.Version); major > newestMajor {
+			newest, newestMajor = candidate, major
+		}
+	}
+	return newest, newestMajor > 0
+}
+
+func pomMajorVersion(version string) int {
+	if m := majorVersionPattern.FindStringSubmatch(version); m != nil {
+		major, _ := strconv.Atoi(m[1])
+		return major
+	}
+	return 0
+}
+
+func (v pomJavaVersion) String() string {
+	return fmt.Sprintf("%s (from %s in pom.xml)", v.Version, v.Source)
+}
@@ -0,0 +1,42 @@
+package jdk
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// RetryPolicy retries transient download failures, such as timeouts, refused
+// connections or 5xx responses, doubling the delay after each attempt.
+type RetryPolicy struct {
+	Attempts int
+	Delay    time.Duration
+}
+
+var (
+	DefaultRetryPolicy = RetryPolicy{Attempts: 3, Delay: time.Second}
+)
+
+// Do calls fn until it succeeds, fails permanently or runs out of attempts. It
+// returns the number of attempts made along with the last error.
+func (p RetryPolicy) Do(out io.Writer, action string, fn func() error) (int, error) {
+	if p.Attempts < 1 {
+		p = DefaultRetryPolicy
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if attempt >= p.Attempts || !isTemporary(err) {
+			return attempt, err
+		}
+
+		delay := p.Delay * time.Duration(1<<uint(attempt-1))
+		fmt.Fprintf(out, "Retrying %s in %s (attempt %d of %d)\n", action, delay, attempt+1, p.Attempts)
+		time.Sleep(delay)
+	}
+}
+
+func isTemporary(err error) bool {
+	dlErr, ok := err.(*DownloadError)
+	return ok && dlErr.Temporary()
+}
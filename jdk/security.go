@@ -0,0 +1,230 @@
+package jdk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/heroku/java-buildpack/util"
+)
+
+const (
+	JdkSecurityFile = ".jdk-security"
+	// SecurityPropertyPrefix marks the system.properties keys that set a
+	// java.security property, such as
+	// java.runtime.security.jdk.tls.disabledAlgorithms. JVM system properties
+	// like java.security.egd keep their own meaning.
+	SecurityPropertyPrefix = "java.runtime.security."
+	// AddProvidersProperty lists security providers to register after the
+	// JDK's own, in the order given
+	AddProvidersProperty = "security.providers.add"
+
+	securityProviderPrefix = "security.provider."
+)
+
+// LoadSecuritySettings reads the java.security properties the app sets in its
+// .jdk-security file and in system.properties, which takes precedence.
+func LoadSecuritySettings(appDir string) (map[string]string, error) {
+	settings := map[string]string{}
+
+	securityFile := filepath.Join(appDir, JdkSecurityFile)
+	if _, err := os.Stat(securityFile); err == nil {
+		props, err := util.ReadPropertiesFile(securityFile)
+		if err != nil {
+			return nil, failedToConfigureSecurity(securityFile, err)
+		}
+		for key, value := range props {
+			settings[key] = value
+		}
+	}
+
+	systemPropertiesFile := filepath.Join(appDir, "system.properties")
+	if _, err := os.Stat(systemPropertiesFile); err == nil {
+		sysProps, err := util.ReadPropertiesFile(systemPropertiesFile)
+		if err != nil {
+			return nil, failedToConfigureSecurity(systemPropertiesFile, err)
+		}
+		for key, value := range sysProps {
+			if strings.HasPrefix(key, SecurityPropertyPrefix) && len(key) > len(SecurityPropertyPrefix) {
+				settings[strings.TrimPrefix(key, SecurityPropertyPrefix)] = value
+			}
+		}
+	}
+
+	if len(settings) == 0 {
+		return nil, nil
+	}
+	return settings, nil
+}
+
+// JavaSecurityPath is the location of java.security, which moved from the JRE
+// to conf/ in JDK 9.
+func JavaSecurityPath(jdk Jdk) string {
+	if jdk.Version.Major >= 9 {
+		return filepath.Join(jdk.Home, "conf", "security", "java.security")
+	}
+
+	if _, err := os.Stat(filepath.Join(jdk.Home, "jre", "lib", "security")); err == nil {
+		return filepath.Join(jdk.Home, "jre", "lib", "security", "java.security")
+	}
+	return filepath.Join(jdk.Home, "lib", "security", "java.security")
+}
+
+// configureSecurity merges the app's settings into the JDK's java.security,
+// replacing properties it already sets and appending the others.
+func (i *Installer) configureSecurity(jdk Jdk, settings map[string]string) error {
+	if len(settings) == 0 {
+		return nil
+	}
+
+	path := JavaSecurityPath(jdk)
+	info, err := os.Stat(path)
+	if err != nil {
+		return failedToConfigureSecurity(path, err)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return failedToConfigureSecurity(path, err)
+	}
+
+	merged, changes := mergeSecurity(string(content), settings)
+	for _, change := range changes {
+		fmt.Fprintf(i.Out, "Setting %s in java.security\n", change)
+	}
+
+	// the file may be a symlink, which must not be written through
+	if err := os.Remove(path); err != nil {
+		return failedToConfigureSecurity(path, err)
+	}
+	if err := ioutil.WriteFile(path, []byte(merged), info.Mode().Perm()); err != nil {
+		return failedToConfigureSecurity(path, err)
+	}
+	return nil
+}
+
+// mergeSecurity sets the properties in the content of a java.security file. It
+// returns the new content and the key=value pairs that were set.
+func mergeSecurity(content string, settings map[string]string) (string, []string) {
+	var keys []string
+	for key := range settings {
+		if key != AddProvidersProperty {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var (
+		lines     []string
+		changes   []string
+		set       = map[string]bool{}
+		providers = map[string]bool{}
+		last      = 0
+	)
+
+	physical := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for n := 0; n < len(physical); n++ {
+		key, value, ok := securityProperty(physical[n])
+		if !ok {
+			lines = append(lines, physical[n])
+			continue
+		}
+
+		// a property's value continues on the next line after a trailing backslash
+		end := n
+		for continued(physical[end]) && end+1 < len(physical) {
+			end++
+			value += strings.TrimSuffix(strings.TrimSpace(physical[end]), "\\")
+		}
+
+		if newValue, ok := settings[key]; ok {
+			if !set[key] {
+				lines = append(lines, key+"="+newValue)
+				changes = append(changes, key+"="+newValue)
+				set[key] = true
+			}
+			value = newValue
+		} else {
+			lines = append(lines, physical[n:end+1]...)
+		}
+		last = countProvider(key, value, last, providers)
+		n = end
+	}
+
+	for _, key := range keys {
+		if !set[key] {
+			lines = append(lines, key+"="+settings[key])
+			changes = append(changes, key+"="+settings[key])
+			last = countProvider(key, settings[key], last, providers)
+		}
+	}
+
+	for _, provider := range strings.Split(settings[AddProvidersProperty], ",") {
+		if provider = strings.TrimSpace(provider); provider != "" && !providers[provider] {
+			last++
+			entry := fmt.Sprintf("%s%d=%s", securityProviderPrefix, last, provider)
+			lines = append(lines, entry)
+			changes = append(changes, entry)
+			providers[provider] = true
+		}
+	}
+
+	return strings.Join(lines, "\n") + "\n", changes
+}
+
+// countProvider records a registered security provider, returning the highest
+// provider number seen.
+func countProvider(key, value string, last int, providers map[string]bool) int {
+	if !strings.HasPrefix(key, securityProviderPrefix) {
+		return last
+	}
+
+	number, err := strconv.Atoi(strings.TrimPrefix(key, securityProviderPrefix))
+	if err != nil {
+		return last
+	}
+
+	if fields := strings.Fields(value); len(fields) > 0 {
+		providers[fields[0]] = true
+	}
+	if number > last {
+		return number
+	}
+	return last
+}
+
+func sameSettings(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+func securityProperty(line string) (string, string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "!") {
+		return "", "", false
+	}
+
+	equal := strings.IndexAny(trimmed, "=:")
+	if equal < 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(trimmed[:equal]), strings.TrimSuffix(strings.TrimSpace(trimmed[equal+1:]), "\\"), true
+}
+
+func continued(line string) bool {
+	trimmed := strings.TrimRight(line, " \t")
+	backslashes := len(trimmed) - len(strings.TrimRight(trimmed, "\\"))
+	return backslashes%2 == 1
+}
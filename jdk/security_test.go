@@ -0,0 +1,174 @@
+package jdk_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/heroku/java-buildpack/jdk"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestSecurity(t *testing.T) {
+	spec.Run(t, "Security", testSecurity, spec.Report(report.Terminal{}))
+}
+
+const javaSecurity = `#
+# The security providers, in order of preference
+#
+security.provider.1=SUN
+security.provider.2=SunRsaSign
+
+# Algorithms disabled for TLS, \
+jdk.tls.disabledAlgorithms=SSLv3, RC4, DES, \
+    MD5withRSA, DH keySize < 1024
+
+securerandom.source=file:/dev/random
+`
+
+func testSecurity(t *testing.T, when spec.G, it spec.S) {
+	var (
+		installer *jdk.Installer
+		layersDir layers.Layers
+		server    *httptest.Server
+		handler   http.HandlerFunc
+		appDir    string
+		out       *strings.Builder
+	)
+
+	it.Before(func() {
+		server = startMirror(&handler)
+
+		out = &strings.Builder{}
+		installer = newInstaller(out)
+		layersDir = tempLayers(t)
+
+		var err error
+		appDir, err = ioutil.TempDir("", "app")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	it.After(func() {
+		stopMirror(server)
+		os.RemoveAll(layersDir.Root)
+		os.RemoveAll(appDir)
+	})
+
+	writeApp := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(appDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	when("#LoadSecuritySettings", func() {
+		it("should prefer system.properties to .jdk-security", func() {
+			writeApp(".jdk-security", "securerandom.source=file:/dev/urandom\njdk.tls.disabledAlgorithms=SSLv3\n")
+			writeApp("system.properties", "java.runtime.version=11\njava.runtime.security.jdk.tls.disabledAlgorithms=SSLv3, TLSv1\n")
+
+			settings, err := jdk.LoadSecuritySettings(appDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(settings) != 2 || settings["securerandom.source"] != "file:/dev/urandom" || settings["jdk.tls.disabledAlgorithms"] != "SSLv3, TLSv1" {
+				t.Fatalf(`security settings did not match: got %v`, settings)
+			}
+		})
+
+		it("should ignore JVM system properties in the java.security namespace", func() {
+			writeApp("system.properties", "java.runtime.version=11\njava.security.egd=file:/dev/./urandom\njava.security.manager=allow\n")
+
+			if settings, err := jdk.LoadSecuritySettings(appDir); err != nil || settings != nil {
+				t.Fatalf(`unexpected security settings: %v %v`, settings, err)
+			}
+		})
+
+		it("should have no settings by default", func() {
+			writeApp("system.properties", "java.runtime.version=11\n")
+
+			if settings, err := jdk.LoadSecuritySettings(appDir); err != nil || settings != nil {
+				t.Fatalf(`unexpected security settings: %v %v`, settings, err)
+			}
+		})
+	})
+
+	when("#Install", func() {
+		it("should merge the settings into conf/security for JDK 11", func() {
+			handler = serveTarball(tarball(
+				tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "conf/security/java.security", Mode: 0644, Body: javaSecurity},
+			))
+			writeApp("system.properties", "java.runtime.version=11\njava.runtime.security.jdk.tls.disabledAlgorithms=SSLv3, TLSv1\njava.runtime.security.crypto.policy=unlimited\n")
+			writeApp(".jdk-security", "security.providers.add=org.bouncycastle.jce.provider.BouncyCastleProvider, SUN\n")
+
+			installed, err := installer.Install(appDir, layersDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			security, err := ioutil.ReadFile(filepath.Join(installed.Home, "conf", "security", "java.security"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := strings.Replace(javaSecurity, "jdk.tls.disabledAlgorithms=SSLv3, RC4, DES, \\\n    MD5withRSA, DH keySize < 1024", "jdk.tls.disabledAlgorithms=SSLv3, TLSv1", 1) +
+				"crypto.policy=unlimited\n" +
+				"security.provider.3=org.bouncycastle.jce.provider.BouncyCastleProvider\n"
+			if string(security) != expected {
+				t.Fatalf(`java.security did not match: got\n%s\nwant\n%s`, security, expected)
+			}
+
+			if !strings.Contains(out.String(), "Setting jdk.tls.disabledAlgorithms=SSLv3, TLSv1 in java.security") {
+				t.Fatalf(`setting not logged: %s`, out)
+			}
+
+			jreSecurity, _ := ioutil.ReadFile(filepath.Join(layersDir.Layer("jre").Root, "conf", "security", "java.security"))
+			if string(jreSecurity) != expected {
+				t.Fatalf(`JRE java.security did not match: got\n%s`, jreSecurity)
+			}
+		})
+
+		it("should merge the settings into the JRE for JDK 8", func() {
+			handler = serveTarball(tarball(
+				tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "jre/bin/java", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "jre/lib/security/java.security", Mode: 0644, Body: javaSecurity},
+			))
+			writeApp("system.properties", "java.runtime.version=1.8\njava.runtime.security.securerandom.source=file:/dev/urandom\n")
+
+			installed, err := installer.Install(appDir, layersDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			security, _ := ioutil.ReadFile(filepath.Join(installed.Home, "jre", "lib", "security", "java.security"))
+			if !strings.Contains(string(security), "\nsecurerandom.source=file:/dev/urandom\n") || strings.Contains(string(security), "file:/dev/random") {
+				t.Fatalf(`java.security did not match: got\n%s`, security)
+			}
+
+			var metadata jdk.Jdk
+			if err := layersDir.Layer("jdk").ReadMetadata(&metadata); err != nil || metadata.Security["securerandom.source"] != "file:/dev/urandom" {
+				t.Fatalf(`JDK layer metadata did not match: got %+v`, metadata)
+			}
+		})
+
+		it("should fail when the JDK has no java.security", func() {
+			handler = serveTarball(tarball(
+				tarEntry{Name: "bin/java", Mode: 0755, Body: "#!/bin/sh"},
+			))
+			writeApp("system.properties", "java.runtime.version=11\njava.runtime.security.crypto.policy=unlimited\n")
+
+			if _, err := installer.Install(appDir, layersDir); err == nil || !strings.Contains(err.Error(), "Failed to configure java.security") {
+				t.Fatalf(`unexpected result: %v`, err)
+			}
+		})
+	})
+}
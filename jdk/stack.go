@@ -0,0 +1,142 @@
+package jdk
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Stack is a platform the buildpack runs on. JDKs built for a stack are found
+// under a path for each architecture it supports, which is usually the stack
+// ID for amd64.
+type Stack struct {
+	ID string
+	// Aliases are other IDs for the same platform, such as the generic CNB
+	// stack the Heroku stack is based on
+	Aliases []string
+	// Paths maps a normalized architecture, amd64 or arm64, to the path of the
+	// stack's JDKs on the mirror
+	Paths map[string]string
+}
+
+// Platform is the stack and architecture JDKs are installed for.
+type Platform struct {
+	Stack string
+	Arch  string
+	// Path is where the platform's JDKs are found on the mirror
+	Path string
+}
+
+var (
+	stacks = map[string]Stack{}
+
+	archAliases = map[string]string{
+		"amd64":   "amd64",
+		"x86_64":  "amd64",
+		"x64":     "amd64",
+		"arm64":   "arm64",
+		"aarch64": "arm64",
+	}
+)
+
+func init() {
+	RegisterStack(Stack{ID: "heroku-16", Aliases: []string{"io.buildpacks.stacks.xenial"}, Paths: map[string]string{"amd64": "heroku-16"}})
+	RegisterStack(Stack{ID: "heroku-18", Aliases: []string{"io.buildpacks.stacks.bionic"}, Paths: map[string]string{"amd64": "heroku-18"}})
+	RegisterStack(Stack{ID: "heroku-20", Aliases: []string{"io.buildpacks.stacks.focal"}, Paths: map[string]string{"amd64": "heroku-20"}})
+	RegisterStack(Stack{ID: "heroku-22", Aliases: []string{"io.buildpacks.stacks.jammy"}, Paths: map[string]string{"amd64": "heroku-22"}})
+	RegisterStack(Stack{ID: "heroku-24", Aliases: []string{"io.buildpacks.stacks.noble"}, Paths: map[string]string{"amd64": "heroku-24/amd64", "arm64": "heroku-24/arm64"}})
+}
+
+func RegisterStack(stack Stack) {
+	stacks[stack.ID] = stack
+}
+
+// StackIDs lists the stacks JDKs can be installed on, without their aliases.
+func StackIDs() []string {
+	var ids []string
+	for id := range stacks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// LookupStack finds a stack by its ID or one of its aliases.
+func LookupStack(id string) (Stack, bool) {
+	if stack, ok := stacks[id]; ok {
+		return stack, true
+	}
+
+	for _, stack := range stacks {
+		if containsString(stack.Aliases, id) {
+			return stack, true
+		}
+	}
+	return Stack{}, false
+}
+
+// CurrentPlatform is the platform of the build, from the STACK set by the
+// lifecycle and the machine's architecture. JDK_ARCH overrides the
+// architecture, for example to install JDKs for another machine.
+func CurrentPlatform() (Platform, error) {
+	id, ok := os.LookupEnv("STACK")
+	if !ok || id == "" {
+		return Platform{}, missingStack(StackIDs())
+	}
+
+	arch, ok := os.LookupEnv("JDK_ARCH")
+	if !ok || arch == "" {
+		arch = machineArch()
+	}
+	return ResolvePlatform(id, arch)
+}
+
+// ResolvePlatform finds where JDKs are published for a stack ID or alias and
+// an architecture, as named by GOARCH or uname.
+func ResolvePlatform(id, arch string) (Platform, error) {
+	stack, ok := LookupStack(id)
+	if !ok {
+		return Platform{}, unknownStack(id, StackIDs())
+	}
+
+	normalized := normalizeArch(arch)
+	path, ok := stack.Paths[normalized]
+	if !ok {
+		var arches []string
+		for supported := range stack.Paths {
+			arches = append(arches, supported)
+		}
+		sort.Strings(arches)
+		return Platform{}, unsupportedArch(stack.ID, arch, arches)
+	}
+
+	return Platform{Stack: stack.ID, Arch: normalized, Path: path}, nil
+}
+
+// machineArch asks uname for the architecture, which is the machine's even
+// when the buildpack binary was built for another one and runs emulated.
+func machineArch() string {
+	if out, err := exec.Command("uname", "-m").Output(); err == nil {
+		if arch := strings.TrimSpace(string(out)); arch != "" {
+			return arch
+		}
+	}
+	return runtime.GOARCH
+}
+
+func normalizeArch(arch string) string {
+	if normalized, ok := archAliases[strings.ToLower(arch)]; ok {
+		return normalized
+	}
+	return strings.ToLower(arch)
+}
+
+// archName is the platform's architecture as a vendor names it.
+func (p Platform) archName(amd64, arm64 string) string {
+	if p.Arch == "arm64" {
+		return arm64
+	}
+	return amd64
+}
@@ -0,0 +1,86 @@
+package jdk_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/heroku/java-buildpack/jdk"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestStack(t *testing.T) {
+	spec.Run(t, "Stack", testStack, spec.Report(report.Terminal{}))
+}
+
+func testStack(t *testing.T, when spec.G, it spec.S) {
+	it.After(func() {
+		os.Setenv("STACK", "heroku-18")
+		os.Unsetenv("JDK_ARCH")
+	})
+
+	when("#ResolvePlatform", func() {
+		for _, tc := range []struct {
+			stack    string
+			arch     string
+			expected jdk.Platform
+		}{
+			{"heroku-18", "amd64", jdk.Platform{Stack: "heroku-18", Arch: "amd64", Path: "heroku-18"}},
+			{"heroku-18", "x86_64", jdk.Platform{Stack: "heroku-18", Arch: "amd64", Path: "heroku-18"}},
+			{"io.buildpacks.stacks.bionic", "amd64", jdk.Platform{Stack: "heroku-18", Arch: "amd64", Path: "heroku-18"}},
+			{"heroku-24", "aarch64", jdk.Platform{Stack: "heroku-24", Arch: "arm64", Path: "heroku-24/arm64"}},
+		} {
+			tc := tc
+			it("should resolve "+tc.stack+" on "+tc.arch, func() {
+				platform, err := jdk.ResolvePlatform(tc.stack, tc.arch)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if platform != tc.expected {
+					t.Fatalf(`platform did not match: got %+v, want %+v`, platform, tc.expected)
+				}
+			})
+		}
+
+		it("should list the supported stacks for an unknown stack", func() {
+			_, err := jdk.ResolvePlatform("cedar-14", "amd64")
+			if err == nil || !strings.Contains(err.Error(), "Unknown stack: cedar-14") || !strings.Contains(err.Error(), "heroku-16, heroku-18") {
+				t.Fatalf(`unexpected result: %v`, err)
+			}
+		})
+
+		it("should list the supported architectures of a stack", func() {
+			_, err := jdk.ResolvePlatform("heroku-18", "aarch64")
+			if err == nil || !strings.Contains(err.Error(), "Supported architectures are: amd64") {
+				t.Fatalf(`unexpected result: %v`, err)
+			}
+		})
+	})
+
+	when("#CurrentPlatform", func() {
+		it("should list the supported stacks when STACK is not set", func() {
+			os.Unsetenv("STACK")
+
+			_, err := jdk.CurrentPlatform()
+			if err == nil || !strings.Contains(err.Error(), "Supported stacks are: heroku-16") {
+				t.Fatalf(`unexpected result: %v`, err)
+			}
+		})
+
+		it("should use the architecture from JDK_ARCH", func() {
+			os.Setenv("STACK", "heroku-24")
+			os.Setenv("JDK_ARCH", "arm64")
+
+			platform, err := jdk.CurrentPlatform()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if platform.Path != "heroku-24/arm64" {
+				t.Fatalf(`platform path did not match: got %s`, platform.Path)
+			}
+		})
+	})
+}
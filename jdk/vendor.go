@@ -0,0 +1,196 @@
+package jdk
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// VendorProvider knows where a JDK vendor publishes its builds and how they
+// are packaged.
+type VendorProvider interface {
+	Name() string
+	Url(v Version, platform Platform) (string, error)
+	// ChecksumUrl is the location of the SHA-256 published for a build, or
+	// empty if the vendor does not publish one next to the archive.
+	ChecksumUrl(jdkUrl string) string
+	Layout() ArchiveLayout
+}
+
+// ArchiveLayout describes where the JDK lives inside a vendor's tarball.
+type ArchiveLayout struct {
+	StripComponents int
+	// Home is the JDK home relative to the stripped archive root, such as
+	// Contents/Home for macOS bundles. Files outside of it are not installed.
+	Home string
+}
+
+var (
+	vendors = map[string]VendorProvider{}
+)
+
+func init() {
+	RegisterVendor(herokuVendor{name: "openjdk", prefix: "openjdk"})
+	RegisterVendor(herokuVendor{name: "zulu", prefix: "zulu-"})
+	RegisterVendor(correttoVendor{})
+	RegisterVendor(temurinVendor{})
+	RegisterVendor(libericaVendor{})
+	RegisterVendor(graalvmVendor{})
+}
+
+func RegisterVendor(provider VendorProvider) {
+	vendors[provider.Name()] = provider
+}
+
+// LookupVendor finds a vendor's provider. When JDK_MIRROR_DIR is set, its
+// JDKs are installed from the mirror directory instead.
+func LookupVendor(name string) (VendorProvider, error) {
+	if provider, ok := vendors[normalizeVendor(name)]; ok {
+		if mirrorDir, ok := os.LookupEnv("JDK_MIRROR_DIR"); ok && mirrorDir != "" {
+			return mirrorVendor{VendorProvider: provider, dir: mirrorDir}, nil
+		}
+		return provider, nil
+	}
+	return nil, unknownVendor(name, VendorNames())
+}
+
+func VendorNames() []string {
+	var names []string
+	for name := range vendors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// herokuVendor builds JDKs mirrored by Heroku, laid out as <platform path>/<vendor><tag>.tar.gz
+type herokuVendor struct {
+	name   string
+	prefix string
+}
+
+func (h herokuVendor) Name() string {
+	return h.name
+}
+
+func (h herokuVendor) Url(v Version, platform Platform) (string, error) {
+	baseUrl := DefaultJdkBaseUrl
+	if customBaseUrl, ok := os.LookupEnv("DEFAULT_JDK_BASE_URL"); ok {
+		baseUrl = customBaseUrl
+	}
+	return fmt.Sprintf("%s/%s/%s%s.tar.gz", baseUrl, platform.Path, h.prefix, v.Tag), nil
+}
+
+func (h herokuVendor) ChecksumUrl(jdkUrl string) string {
+	return jdkUrl + ".sha256"
+}
+
+func (h herokuVendor) Layout() ArchiveLayout {
+	return ArchiveLayout{}
+}
+
+// mirrorVendor installs a vendor's tarballs from a local directory laid out as
+// <platform path>/<vendor>/<tag>.tar.gz, with an optional <tag>.tar.gz.sha256 next to each.
+type mirrorVendor struct {
+	VendorProvider
+	dir string
+}
+
+func (m mirrorVendor) Url(v Version, platform Platform) (string, error) {
+	dir, err := filepath.Abs(m.dir)
+	if err != nil {
+		return "", err
+	}
+
+	mirrorUrl := url.URL{
+		Scheme: "file",
+		Path:   filepath.ToSlash(filepath.Join(dir, filepath.FromSlash(platform.Path), m.Name(), v.Tag+".tar.gz")),
+	}
+	return mirrorUrl.String(), nil
+}
+
+func (m mirrorVendor) ChecksumUrl(jdkUrl string) string {
+	return jdkUrl + ".sha256"
+}
+
+type correttoVendor struct{}
+
+func (correttoVendor) Name() string {
+	return "corretto"
+}
+
+func (correttoVendor) Url(v Version, platform Platform) (string, error) {
+	return fmt.Sprintf("https://corretto.aws/downloads/resources/%s/amazon-corretto-%s-linux-%s.tar.gz", v.Tag, v.Tag, platform.archName("x64", "aarch64")), nil
+}
+
+func (correttoVendor) ChecksumUrl(jdkUrl string) string {
+	return ""
+}
+
+func (correttoVendor) Layout() ArchiveLayout {
+	return ArchiveLayout{StripComponents: 1}
+}
+
+type temurinVendor struct{}
+
+func (temurinVendor) Name() string {
+	return "temurin"
+}
+
+// Url handles both tag styles used by Adoptium, 17.0.8+7 and 8u382-b05.
+func (temurinVendor) Url(v Version, platform Platform) (string, error) {
+	release := "jdk-" + strings.Replace(v.Tag, "+", "%2B", -1)
+	file := strings.Replace(v.Tag, "+", "_", -1)
+	if strings.Contains(v.Tag, "u") {
+		release = "jdk" + v.Tag
+		file = strings.Replace(v.Tag, "-", "", -1)
+	}
+	return fmt.Sprintf("https://github.com/adoptium/temurin%d-binaries/releases/download/%s/OpenJDK%dU-jdk_%s_linux_hotspot_%s.tar.gz", v.Major, release, v.Major, platform.archName("x64", "aarch64"), file), nil
+}
+
+func (temurinVendor) ChecksumUrl(jdkUrl string) string {
+	return jdkUrl + ".sha256.txt"
+}
+
+func (temurinVendor) Layout() ArchiveLayout {
+	return ArchiveLayout{StripComponents: 1}
+}
+
+type libericaVendor struct{}
+
+func (libericaVendor) Name() string {
+	return "liberica"
+}
+
+func (libericaVendor) Url(v Version, platform Platform) (string, error) {
+	return fmt.Sprintf("https://download.bell-sw.com/java/%s/bellsoft-jdk%s-linux-%s.tar.gz", v.Tag, v.Tag, platform.archName("amd64", "aarch64")), nil
+}
+
+func (libericaVendor) ChecksumUrl(jdkUrl string) string {
+	return ""
+}
+
+func (libericaVendor) Layout() ArchiveLayout {
+	return ArchiveLayout{StripComponents: 1}
+}
+
+type graalvmVendor struct{}
+
+func (graalvmVendor) Name() string {
+	return "graalvm"
+}
+
+func (graalvmVendor) Url(v Version, platform Platform) (string, error) {
+	return fmt.Sprintf("https://github.com/graalvm/graalvm-ce-builds/releases/download/jdk-%s/graalvm-community-jdk-%s_linux-%s_bin.tar.gz", v.Tag, v.Tag, platform.archName("x64", "aarch64")), nil
+}
+
+func (graalvmVendor) ChecksumUrl(jdkUrl string) string {
+	return jdkUrl + ".sha256"
+}
+
+func (graalvmVendor) Layout() ArchiveLayout {
+	return ArchiveLayout{StripComponents: 1}
+}
@@ -0,0 +1,200 @@
+package jdk_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/buildpack/libbuildpack/logger"
+	"github.com/google/go-cmp/cmp"
+	"github.com/heroku/java-buildpack/jdk"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestVendor(t *testing.T) {
+	spec.Run(t, "Vendor", testVendor, spec.Report(report.Terminal{}))
+}
+
+type testVendorProvider struct {
+	url string
+}
+
+func (p testVendorProvider) Name() string {
+	return "testvendor"
+}
+
+func (p testVendorProvider) Url(v jdk.Version, platform jdk.Platform) (string, error) {
+	return fmt.Sprintf("%s/testvendor-%s.tar.gz", p.url, v.Tag), nil
+}
+
+func (p testVendorProvider) ChecksumUrl(jdkUrl string) string {
+	return ""
+}
+
+func (p testVendorProvider) Layout() jdk.ArchiveLayout {
+	return jdk.ArchiveLayout{StripComponents: 1, Home: "Contents/Home"}
+}
+
+func testVendor(t *testing.T, when spec.G, it spec.S) {
+	var catalog jdk.Catalog
+
+	it.Before(func() {
+		wd, _ := os.Getwd()
+		os.Setenv("STACK", "heroku-18")
+
+		var err error
+		catalog, err = jdk.LoadCatalog(filepath.Join(wd, ".."))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	when("#GetVersionUrl", func() {
+		for _, tc := range []struct {
+			version  string
+			expected string
+		}{
+			{"corretto-17.0.8.8.1", "https://corretto.aws/downloads/resources/17.0.8.8.1/amazon-corretto-17.0.8.8.1-linux-x64.tar.gz"},
+			{"temurin-17.0.8+7", "https://github.com/adoptium/temurin17-binaries/releases/download/jdk-17.0.8%2B7/OpenJDK17U-jdk_x64_linux_hotspot_17.0.8_7.tar.gz"},
+			{"temurin-8u382-b05", "https://github.com/adoptium/temurin8-binaries/releases/download/jdk8u382-b05/OpenJDK8U-jdk_x64_linux_hotspot_8u382b05.tar.gz"},
+			{"liberica-17.0.8+7", "https://download.bell-sw.com/java/17.0.8+7/bellsoft-jdk17.0.8+7-linux-amd64.tar.gz"},
+			{"graalvm-17.0.8", "https://github.com/graalvm/graalvm-ce-builds/releases/download/jdk-17.0.8/graalvm-community-jdk-17.0.8_linux-x64_bin.tar.gz"},
+		} {
+			tc := tc
+			it(fmt.Sprintf("should get %s", tc.version), func() {
+				v, err := catalog.ParseVersionString(tc.version)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				url, err := catalog.GetVersionUrl(v)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if diff := cmp.Diff(url, tc.expected); diff != "" {
+					t.Fatalf(`URL did not match: (-got +want)\n%s`, diff)
+				}
+			})
+		}
+	})
+
+	when("#GetVersionUrl on arm64", func() {
+		it("should get the vendor's arm64 build", func() {
+			os.Setenv("STACK", "heroku-24")
+			os.Setenv("JDK_ARCH", "aarch64")
+			defer os.Setenv("STACK", "heroku-18")
+			defer os.Unsetenv("JDK_ARCH")
+
+			v, err := catalog.ParseVersionString("temurin-17.0.8+7")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			url, err := catalog.GetVersionUrl(v)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := "https://github.com/adoptium/temurin17-binaries/releases/download/jdk-17.0.8%2B7/OpenJDK17U-jdk_aarch64_linux_hotspot_17.0.8_7.tar.gz"
+			if diff := cmp.Diff(url, expected); diff != "" {
+				t.Fatalf(`URL did not match: (-got +want)\n%s`, diff)
+			}
+		})
+	})
+
+	when("#ParseVersionString", func() {
+		it("should resolve a vendor's major version", func() {
+			v, err := catalog.ParseVersionString("corretto-17")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			latest, _ := catalog.LatestVersion("corretto", 17)
+			if v.Vendor != "corretto" || v.Major != 17 || v.Tag != latest.Tag {
+				t.Fatalf(`JDK version did not match: got %+v, want %+v`, v, latest)
+			}
+		})
+
+		it("should reject unknown vendors", func() {
+			_, err := catalog.ParseVersionString("acme-17")
+			if err == nil {
+				t.Fatal("unexpected success")
+			}
+
+			if !strings.Contains(err.Error(), "temurin") {
+				t.Fatalf(`error does not list supported vendors: %s`, err)
+			}
+		})
+	})
+
+	when("#RegisterVendor", func() {
+		var (
+			layersDir layers.Layers
+			server    *httptest.Server
+		)
+
+		it.Before(func() {
+			server = httptest.NewServer(serveTarball(tarball(
+				tarEntry{Name: "test.jdk/Contents/Info.plist", Mode: 0644, Body: "<plist/>"},
+				tarEntry{Name: "test.jdk/Contents/Home/bin/java", Mode: 0755, Body: "#!/bin/sh"},
+				tarEntry{Name: "test.jdk/Contents/Home/release", Mode: 0644, Body: `JAVA_VERSION="17.0.8"`},
+			)))
+			jdk.RegisterVendor(testVendorProvider{url: server.URL})
+
+			layersRoot, err := ioutil.TempDir("", "layers")
+			if err != nil {
+				t.Fatal(err)
+			}
+			layersDir = layers.NewLayers(layersRoot, logger.DefaultLogger())
+		})
+
+		it.After(func() {
+			server.Close()
+			os.RemoveAll(layersDir.Root)
+		})
+
+		it("should install using the vendor's archive layout", func() {
+			wd, _ := os.Getwd()
+			appDir, err := ioutil.TempDir("", "app")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(appDir)
+
+			if err := ioutil.WriteFile(filepath.Join(appDir, "system.properties"), []byte("java.runtime.version=testvendor-17.0.8"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			installer := &jdk.Installer{
+				In:           []byte{},
+				Out:          ioutil.Discard,
+				Err:          ioutil.Discard,
+				BuildpackDir: filepath.Join(wd, ".."),
+			}
+
+			installed, err := installer.Install(appDir, layersDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if installed.Version.Vendor != "testvendor" || installed.Version.Major != 17 {
+				t.Fatalf(`JDK version did not match: got %+v`, installed.Version)
+			}
+
+			if _, err := os.Stat(filepath.Join(installed.Home, "bin", "java")); err != nil {
+				t.Fatal("java not installed in the JDK home")
+			}
+
+			if _, err := os.Stat(filepath.Join(installed.Home, "Info.plist")); !os.IsNotExist(err) {
+				t.Fatal("files outside of the JDK home were installed")
+			}
+		})
+	})
+}
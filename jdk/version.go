@@ -0,0 +1,255 @@
+package jdk
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VersionNumber is a comparable JDK version. Legacy versions such as 1.8.0_191
+// or 8u191 are normalized to their JEP 223 equivalent, so they equal 8.0.191.
+type VersionNumber struct {
+	Parts []int
+	Build int
+}
+
+var (
+	legacyVersionPattern = regexp.MustCompile(`^1\.([0-9]+)(?:\.([0-9]+))?(?:_([0-9]+))?(?:-b([0-9]+))?$`)
+	modernVersionPattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)*)(?:[+-]([0-9]+))?$`)
+	updateVersionPattern = regexp.MustCompile(`^([0-9]+)u([0-9]+)(?:-b([0-9]+))?$`)
+)
+
+func ParseVersionNumber(s string) (VersionNumber, error) {
+	if m := legacyVersionPattern.FindStringSubmatch(s); m != nil {
+		return VersionNumber{
+			Parts: []int{atoi(m[1]), atoi(m[2]), atoi(m[3])},
+			Build: atoi(m[4]),
+		}, nil
+	} else if m := updateVersionPattern.FindStringSubmatch(s); m != nil {
+		return VersionNumber{
+			Parts: []int{atoi(m[1]), 0, atoi(m[2])},
+			Build: atoi(m[3]),
+		}, nil
+	} else if m := modernVersionPattern.FindStringSubmatch(s); m != nil {
+		var parts []int
+		for _, p := range strings.Split(m[1], ".") {
+			parts = append(parts, atoi(p))
+		}
+		return VersionNumber{
+			Parts: parts,
+			Build: atoi(m[2]),
+		}, nil
+	}
+	return VersionNumber{}, errors.New(fmt.Sprintf("unparseable version number: %s", s))
+}
+
+func (v VersionNumber) Major() int {
+	return v.part(0)
+}
+
+// Compare returns -1, 0 or 1 when v is older than, the same as, or newer than o.
+func (v VersionNumber) Compare(o VersionNumber) int {
+	n := len(v.Parts)
+	if len(o.Parts) > n {
+		n = len(o.Parts)
+	}
+
+	for i := 0; i < n; i++ {
+		if c := compareInts(v.part(i), o.part(i)); c != 0 {
+			return c
+		}
+	}
+	return compareInts(v.Build, o.Build)
+}
+
+func (v VersionNumber) String() string {
+	var parts []string
+	for _, p := range v.Parts {
+		parts = append(parts, strconv.Itoa(p))
+	}
+
+	s := strings.Join(parts, ".")
+	if v.Build > 0 {
+		s = fmt.Sprintf("%s+%d", s, v.Build)
+	}
+	return s
+}
+
+func (v VersionNumber) part(i int) int {
+	if i < len(v.Parts) {
+		return v.Parts[i]
+	}
+	return 0
+}
+
+// VersionConstraint selects JDK versions, for example "11.x", ">=11.0.2",
+// ">=11 <12" or "17-lts". Every term of a constraint must match.
+type VersionConstraint struct {
+	Lts    bool
+	source string
+	terms  []versionTerm
+}
+
+type versionTerm struct {
+	op      string
+	version VersionNumber
+	// prefix is the number of leading parts that must match exactly for
+	// wildcard terms like 11.x (1) or 11.0.x (2)
+	prefix int
+}
+
+var (
+	constraintTermPattern = regexp.MustCompile(`^(>=|<=|>|<|=)?([0-9][0-9._]*?)((?:\.[xX*])?)(?:[+]([0-9]+))?$`)
+)
+
+func IsVersionConstraint(s string) bool {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return false
+	} else if s == "lts" || strings.HasSuffix(s, "-lts") {
+		return true
+	}
+	return strings.ContainsAny(s[:1], "<>=") || (strings.ContainsAny(s[:1], "0123456789") && strings.ContainsAny(s, "xX* ,"))
+}
+
+func ParseVersionConstraint(s string) (VersionConstraint, error) {
+	s = strings.TrimSpace(s)
+	constraint := VersionConstraint{source: s}
+
+	if s == "lts" {
+		constraint.Lts = true
+		return constraint, nil
+	} else if strings.HasSuffix(s, "-lts") {
+		constraint.Lts = true
+		s = strings.TrimSuffix(s, "-lts")
+	}
+
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' }) {
+		m := constraintTermPattern.FindStringSubmatch(field)
+		if m == nil {
+			return VersionConstraint{}, errors.New(fmt.Sprintf("unparseable version constraint: %s", s))
+		}
+
+		version, err := ParseVersionNumber(strings.TrimSuffix(m[2], "."))
+		if err != nil {
+			return VersionConstraint{}, err
+		}
+		version.Build = atoi(m[4])
+
+		term := versionTerm{op: m[1], version: version}
+		if m[3] != "" || m[1] == "" {
+			// a bare version like 11 or 11.0 matches every release that starts with it
+			term.op = "="
+			term.prefix = len(strings.Split(m[2], "."))
+			if legacyVersionPattern.MatchString(m[2]) {
+				term.prefix--
+			}
+			if m[3] == "" && (term.prefix > 2 || version.Build > 0 || strings.Contains(m[2], "_")) {
+				term.prefix = 0
+			}
+		}
+		constraint.terms = append(constraint.terms, term)
+	}
+
+	if len(constraint.terms) == 0 {
+		return VersionConstraint{}, errors.New(fmt.Sprintf("unparseable version constraint: %s", s))
+	}
+	return constraint, nil
+}
+
+func (c VersionConstraint) String() string {
+	return c.source
+}
+
+func (c VersionConstraint) Matches(v VersionNumber) bool {
+	for _, t := range c.terms {
+		if !t.matches(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t versionTerm) matches(v VersionNumber) bool {
+	if t.prefix > 0 {
+		for i := 0; i < t.prefix; i++ {
+			if v.part(i) != t.version.part(i) {
+				return false
+			}
+		}
+		return true
+	}
+
+	c := v.Compare(t.version)
+	if t.version.Build == 0 {
+		c = VersionNumber{Parts: v.Parts}.Compare(t.version)
+	}
+
+	switch t.op {
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	default:
+		return c == 0
+	}
+}
+
+// Resolve finds the newest version of a vendor's JDK in the catalog that
+// satisfies the constraint and is published for the stack of the build.
+func (c Catalog) Resolve(vendor string, constraint VersionConstraint) (Version, error) {
+	var (
+		best    Version
+		bestNum VersionNumber
+		found   bool
+	)
+
+	platform, err := CurrentPlatform()
+	if err != nil {
+		return Version{}, err
+	}
+
+	for _, entry := range c.Jdks {
+		if normalizeVendor(entry.Vendor) != normalizeVendor(vendor) || (constraint.Lts && !entry.Lts) || !entry.SupportsStack(platform.Stack) {
+			continue
+		}
+
+		for _, tag := range entry.Tags() {
+			num, err := ParseVersionNumber(tag)
+			if err != nil || !constraint.Matches(num) {
+				continue
+			}
+
+			if !found || num.Compare(bestNum) > 0 {
+				best = Version{Vendor: vendor, Tag: tag, Major: entry.Major}
+				bestNum = num
+				found = true
+			}
+		}
+	}
+
+	if !found {
+		return Version{}, errors.New(fmt.Sprintf("no %s JDK in the catalog satisfies %s on %s", normalizeVendor(vendor), constraint, platform.Stack))
+	}
+	return best, nil
+}
+
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+func atoi(s string) int {
+	i, _ := strconv.Atoi(s)
+	return i
+}
@@ -0,0 +1,224 @@
+package jdk_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/heroku/java-buildpack/jdk"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestVersion(t *testing.T) {
+	spec.Run(t, "Version", testVersion, spec.Report(report.Terminal{}))
+}
+
+const versionCatalogToml = `
+[[jdk]]
+vendor = "openjdk"
+major = 8
+lts = true
+versions = ["1.8.0_181", "1.8.0_191"]
+
+[[jdk]]
+vendor = "openjdk"
+major = 11
+lts = true
+latest = "11.0.2"
+versions = ["11.0.1", "11.0.2"]
+
+[[jdk]]
+vendor = "openjdk"
+major = 12
+versions = ["12.0.1"]
+
+[[jdk]]
+vendor = "openjdk"
+major = 21
+lts = true
+versions = ["21.0.1"]
+stacks = ["heroku-22"]
+
+[[jdk]]
+vendor = "zulu"
+major = 11
+lts = true
+versions = ["11.0.1"]
+`
+
+func testVersion(t *testing.T, when spec.G, it spec.S) {
+	when("#ParseVersionNumber", func() {
+		it("should parse legacy versions", func() {
+			v, err := jdk.ParseVersionNumber("1.8.0_191")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if v.Major() != 8 || v.String() != "8.0.191" {
+				t.Fatalf(`version did not match: got %s, want %s`, v, "8.0.191")
+			}
+		})
+
+		it("should parse JEP 223 versions", func() {
+			v, err := jdk.ParseVersionNumber("11.0.1+13")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if v.Major() != 11 || v.Build != 13 || v.String() != "11.0.1+13" {
+				t.Fatalf(`version did not match: got %s, want %s`, v, "11.0.1+13")
+			}
+		})
+
+		it("should not parse garbage", func() {
+			if _, err := jdk.ParseVersionNumber("1bh"); err == nil {
+				t.Fatal("unexpected success")
+			}
+		})
+	})
+
+	when("#Compare", func() {
+		it("should order versions", func() {
+			ordered := []string{"1.7.0_201", "1.8.0_181", "1.8.0_191", "9-181", "9.0.4", "11", "11.0.1", "11.0.1+13", "11.0.2"}
+			for n := 1; n < len(ordered); n++ {
+				older, _ := jdk.ParseVersionNumber(ordered[n-1])
+				newer, _ := jdk.ParseVersionNumber(ordered[n])
+
+				if older.Compare(newer) != -1 || newer.Compare(older) != 1 {
+					t.Fatalf(`expected %s to be older than %s`, ordered[n-1], ordered[n])
+				}
+			}
+		})
+
+		it("should treat legacy and modern forms as equal", func() {
+			legacy, _ := jdk.ParseVersionNumber("1.8.0_191")
+			modern, _ := jdk.ParseVersionNumber("8.0.191")
+
+			if legacy.Compare(modern) != 0 {
+				t.Fatal("expected versions to be equal")
+			}
+		})
+	})
+
+	when("#ParseVersionConstraint", func() {
+		matches := func(constraint, version string) bool {
+			c, err := jdk.ParseVersionConstraint(constraint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, err := jdk.ParseVersionNumber(version)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return c.Matches(v)
+		}
+
+		it("should match wildcards", func() {
+			if !matches("11.x", "11.0.2") || matches("11.x", "12.0.1") {
+				t.Fatal("11.x did not match correctly")
+			}
+
+			if !matches("11.0.x", "11.0.1+13") || matches("11.0.x", "11.1.0") {
+				t.Fatal("11.0.x did not match correctly")
+			}
+
+			if !matches("1.8.x", "1.8.0_191") || matches("1.8.x", "11.0.1") {
+				t.Fatal("1.8.x did not match correctly")
+			}
+		})
+
+		it("should match ranges", func() {
+			if !matches(">=11.0.2", "11.0.2") || !matches(">=11.0.2", "12.0.1") || matches(">=11.0.2", "11.0.1") {
+				t.Fatal(">=11.0.2 did not match correctly")
+			}
+
+			if !matches(">=11 <12", "11.0.1") || matches(">=11 <12", "12.0.1") {
+				t.Fatal(">=11 <12 did not match correctly")
+			}
+
+			if !matches(">1.8.0_181", "1.8.0_191") || matches(">1.8.0_181", "1.8.0_181") {
+				t.Fatal(">1.8.0_181 did not match correctly")
+			}
+		})
+
+		it("should not parse garbage", func() {
+			if _, err := jdk.ParseVersionConstraint(">=eleven"); err == nil {
+				t.Fatal("unexpected success")
+			}
+		})
+	})
+
+	when("#Resolve", func() {
+		var catalog jdk.Catalog
+
+		it.Before(func() {
+			os.Setenv("STACK", "heroku-18")
+
+			var err error
+			catalog, err = jdk.ReadCatalog(strings.NewReader(versionCatalogToml))
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+
+		resolve := func(s string) jdk.Version {
+			v, err := catalog.ParseVersionString(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return v
+		}
+
+		it("should resolve the newest matching version", func() {
+			if v := resolve("11.x"); v.Tag != "11.0.2" || v.Major != 11 {
+				t.Fatalf(`JDK version did not match: got %s, want %s`, v.Tag, "11.0.2")
+			}
+
+			if v := resolve(">=11.0.2"); v.Tag != "12.0.1" {
+				t.Fatalf(`JDK version did not match: got %s, want %s`, v.Tag, "12.0.1")
+			}
+
+			if v := resolve("<1.8.0_191"); v.Tag != "1.8.0_181" {
+				t.Fatalf(`JDK version did not match: got %s, want %s`, v.Tag, "1.8.0_181")
+			}
+		})
+
+		it("should resolve LTS releases", func() {
+			if v := resolve("11-lts"); v.Tag != "11.0.2" {
+				t.Fatalf(`JDK version did not match: got %s, want %s`, v.Tag, "11.0.2")
+			}
+
+			if v := resolve("lts"); v.Tag != "11.0.2" {
+				t.Fatalf(`JDK version did not match: got %s, want %s`, v.Tag, "11.0.2")
+			}
+
+			if _, err := catalog.ParseVersionString("12-lts"); err == nil {
+				t.Fatal("unexpected success")
+			}
+		})
+
+		it("should skip versions not published for the stack", func() {
+			if v := resolve("lts"); v.Tag != "11.0.2" {
+				t.Fatalf(`JDK version did not match: got %s, want %s`, v.Tag, "11.0.2")
+			}
+
+			if _, err := catalog.ParseVersionString("21.x"); err == nil {
+				t.Fatal("unexpected success")
+			}
+
+			os.Setenv("STACK", "heroku-22")
+			defer os.Setenv("STACK", "heroku-18")
+
+			if v := resolve(">=11.0.2"); v.Tag != "21.0.1" {
+				t.Fatalf(`JDK version did not match: got %s, want %s`, v.Tag, "21.0.1")
+			}
+		})
+
+		it("should resolve vendor constraints", func() {
+			if v := resolve("zulu-11.x"); v.Tag != "11.0.1" || v.Vendor != "zulu-" {
+				t.Fatalf(`JDK version did not match: got %s%s, want %s`, v.Vendor, v.Tag, "zulu-11.0.1")
+			}
+		})
+	})
+}
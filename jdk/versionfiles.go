@@ -0,0 +1,139 @@
+package jdk
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// sdkmanVendors maps the suffix of an sdkman identifier, as in 17.0.8-tem
+	sdkmanVendors = map[string]string{
+		"amzn":    "corretto",
+		"graal":   "graalvm",
+		"graalce": "graalvm",
+		"librca":  "liberica",
+		"open":    "openjdk",
+		"tem":     "temurin",
+		"zulu":    "zulu",
+	}
+
+	// asdfVendors maps the prefix of an asdf-java identifier, as in
+	// temurin-17.0.8+7, where it differs from the vendor's name in the catalog
+	asdfVendors = map[string]string{
+		"adoptopenjdk":      "temurin",
+		"graalvm-community": "graalvm",
+	}
+)
+
+// versionFile reads a JDK version pinned by a local version manager, in order
+// of precedence: jenv's .java-version, then .sdkmanrc, then asdf's .tool-versions.
+// The version is returned in the form ParseVersionString accepts, along with
+// the file it came from.
+func versionFile(appDir string) (string, string, bool) {
+	for _, f := range []struct {
+		name  string
+		parse func(string) (string, bool)
+	}{
+		{".java-version", parseJavaVersionFile},
+		{".sdkmanrc", parseSdkmanrc},
+		{".tool-versions", parseToolVersions},
+	} {
+		if version, ok := f.parse(filepath.Join(appDir, f.name)); ok {
+			return version, f.name, true
+		}
+	}
+	return "", "", false
+}
+
+func parseJavaVersionFile(path string) (string, bool) {
+	lines, err := readVersionFileLines(path)
+	if err != nil || len(lines) == 0 {
+		return "", false
+	}
+
+	// jenv names JDKs like temurin64-17.0.8
+	version := lines[0]
+	if trimmed := strings.Replace(version, "64-", "-", 1); vendorVersionPattern.MatchString(trimmed) {
+		version = trimmed
+	}
+	return version, true
+}
+
+func parseSdkmanrc(path string) (string, bool) {
+	lines, err := readVersionFileLines(path)
+	if err != nil {
+		return "", false
+	}
+
+	for _, line := range lines {
+		if equal := strings.Index(line, "="); equal >= 0 && strings.TrimSpace(line[:equal]) == "java" {
+			return sdkmanVersion(strings.TrimSpace(line[equal+1:])), true
+		}
+	}
+	return "", false
+}
+
+func parseToolVersions(path string) (string, bool) {
+	lines, err := readVersionFileLines(path)
+	if err != nil {
+		return "", false
+	}
+
+	for _, line := range lines {
+		// a tool may list several versions, the first is the one in use
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "java" {
+			return asdfVersion(fields[1]), true
+		}
+	}
+	return "", false
+}
+
+// sdkmanVersion turns 17.0.8-tem into temurin-17.0.8. Unknown vendors are
+// kept so that ParseVersionString reports them.
+func sdkmanVersion(id string) string {
+	dash := strings.LastIndex(id, "-")
+	if dash < 0 {
+		return id
+	}
+
+	version, vendor := id[:dash], id[dash+1:]
+	if name, ok := sdkmanVendors[vendor]; ok {
+		vendor = name
+	}
+	return vendor + "-" + version
+}
+
+// asdfVersion turns adoptopenjdk-17.0.8+7 into temurin-17.0.8+7.
+func asdfVersion(id string) string {
+	for prefix, vendor := range asdfVendors {
+		if strings.HasPrefix(id, prefix+"-") {
+			return vendor + strings.TrimPrefix(id, prefix)
+		}
+	}
+	return id
+}
+
+// readVersionFileLines returns the non-empty lines of a file, without comments.
+func readVersionFileLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if hash := strings.Index(line, "#"); hash >= 0 {
+			line = line[:hash]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,48 @@
+package maven
+
+import (
+	"time"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/heroku/java-buildpack/util"
+)
+
+// Maven is the metadata of the maven layer.
+type Maven struct {
+	Version string `toml:"version"`
+	Url     string `toml:"url"`
+	Sha256  string `toml:"sha256"`
+	// Checksum is the digest Apache published for the download, such as
+	// "sha512:<digest>", which decides whether the layer can be reused
+	Checksum    string    `toml:"checksum"`
+	Stack       string    `toml:"stack"`
+	InstalledAt time.Time `toml:"installed_at"`
+}
+
+// BomEntries lists the Maven installed in the maven layer, or fetched for the
+// app's Maven wrapper, for the image's bill of materials.
+func BomEntries(layersDir layers.Layers) ([]util.BomEntry, error) {
+	var entries []util.BomEntry
+	for _, name := range []string{"maven", WrapperLayerName} {
+		var installed Maven
+		if err := layersDir.Layer(name).ReadMetadata(&installed); err != nil {
+			return nil, err
+		} else if installed.Url == "" {
+			continue
+		}
+
+		entries = append(entries, util.BomEntry{
+			Name:    "maven",
+			Version: installed.Version,
+			Metadata: util.BomMetadata{
+				Vendor:      "apache",
+				Url:         installed.Url,
+				Sha256:      installed.Sha256,
+				Stack:       installed.Stack,
+				InstalledAt: installed.InstalledAt,
+				Layer:       name,
+			},
+		})
+	}
+	return entries, nil
+}
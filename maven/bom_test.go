@@ -0,0 +1,66 @@
+package maven_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/buildpack/libbuildpack/logger"
+	"github.com/heroku/java-buildpack/maven"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestBom(t *testing.T) {
+	spec.Run(t, "Bom", testBom, spec.Report(report.Terminal{}))
+}
+
+func testBom(t *testing.T, when spec.G, it spec.S) {
+	var layersDir layers.Layers
+
+	it.Before(func() {
+		root, err := ioutil.TempDir("", "layers")
+		if err != nil {
+			t.Fatal(err)
+		}
+		layersDir = layers.NewLayers(root, logger.DefaultLogger())
+	})
+
+	it.After(func() {
+		os.RemoveAll(layersDir.Root)
+	})
+
+	when("#BomEntries", func() {
+		it("should list the installed Maven", func() {
+			metadata := `[metadata]
+version = "3.5.4"
+url = "https://apache.org/dist/maven/maven-3/3.5.4/binaries/apache-maven-3.5.4-bin.tar.gz"
+sha256 = "abcdef"
+stack = "heroku-18"
+installed_at = 2019-03-01T12:00:00Z
+`
+			ioutil.WriteFile(filepath.Join(layersDir.Root, "maven.toml"), []byte(metadata), 0644)
+
+			entries, err := maven.BomEntries(layersDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(entries) != 1 || entries[0].Name != "maven" || entries[0].Version != "3.5.4" {
+				t.Fatalf(`BOM entries did not match: got %+v`, entries)
+			}
+
+			if entries[0].Metadata.Sha256 != "abcdef" || entries[0].Metadata.Stack != "heroku-18" || entries[0].Metadata.InstalledAt.Year() != 2019 {
+				t.Fatalf(`BOM metadata did not match: got %+v`, entries[0].Metadata)
+			}
+		})
+
+		it("should have no entries when Maven isn't installed", func() {
+			if entries, err := maven.BomEntries(layersDir); err != nil || len(entries) != 0 {
+				t.Fatalf(`unexpected BOM entries: %+v %v`, entries, err)
+			}
+		})
+	})
+}
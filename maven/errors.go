@@ -30,3 +30,23 @@ func failedToDownloadSettings(cause error) error {
 func failedToDownloadSettingsFromUrl(url string, cause error) error {
 	return errorWithCause(fmt.Sprintf("Failed to download settings.xml from URL: %s", url), cause)
 }
+
+func failedToInstallMaven(version string, cause error) error {
+	return errorWithCause(fmt.Sprintf("Failed to install Maven %s", version), cause)
+}
+
+func invalidMavenVersion(version string) error {
+	return errorWithCause(fmt.Sprintf("Invalid Maven version: %s", version), errors.New(fmt.Sprintf("set %s in system.properties to a Maven release, such as %s", MavenVersionProperty, DefaultMavenVersion)))
+}
+
+func invalidMavenChecksum(url, expected, actual string) error {
+	return errors.New(fmt.Sprintf("checksum verification failed for %s: expected %s but downloaded %s", url, expected, actual))
+}
+
+func failedToFetchWrapperDistribution(url string, cause error) error {
+	return errorWithCause(fmt.Sprintf("Failed to download the Maven wrapper distribution from %s", url), cause)
+}
+
+func failedToWriteMirrorSettings(cause error) error {
+	return errorWithCause("Failed to write the Maven settings for MAVEN_MIRROR_URL", cause)
+}
@@ -0,0 +1,252 @@
+package maven
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/heroku/java-buildpack/util"
+)
+
+const (
+	// DefaultMavenVersion is installed for apps that don't set maven.version
+	DefaultMavenVersion = "3.5.4"
+	// MavenVersionProperty sets the Maven version in system.properties
+	MavenVersionProperty = "maven.version"
+	// DefaultMavenBaseUrl keeps every Maven release, unlike the Apache mirrors
+	DefaultMavenBaseUrl = "https://archive.apache.org/dist/maven"
+)
+
+var (
+	httpClient   = util.NewHttpClient()
+	mavenVersion = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+(-[A-Za-z0-9.-]+)?$`)
+)
+
+// checksumAlgorithms are the digests Maven distributions are verified with.
+// Apache publishes a SHA-512, or only a SHA-1 for older releases, and the
+// Maven wrapper's distributionSha256Sum is a SHA-256.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"sha512": sha512.New,
+	"sha256": sha256.New,
+	"sha1":   sha1.New,
+}
+
+// MavenVersion is the version of Maven the app is built with: maven.version in
+// system.properties, or else MAVEN_VERSION, or else the default.
+func MavenVersion(appDir string) (string, error) {
+	version := DefaultMavenVersion
+	if sysProps, err := util.ReadPropertiesFile(filepath.Join(appDir, "system.properties")); err == nil && sysProps[MavenVersionProperty] != "" {
+		version = sysProps[MavenVersionProperty]
+	} else if env := os.Getenv("MAVEN_VERSION"); env != "" {
+		version = env
+	}
+
+	if !mavenVersion.MatchString(version) {
+		return "", invalidMavenVersion(version)
+	}
+	return version, nil
+}
+
+// MavenUrl is where a Maven release's binary tarball is downloaded from.
+// MAVEN_BASE_URL replaces the Apache archive with a mirror of the same layout,
+// or else MAVEN_MIRROR_URL replaces it with the release in a Maven repository.
+func MavenUrl(version string) string {
+	baseUrl := DefaultMavenBaseUrl
+	if env := os.Getenv("MAVEN_BASE_URL"); env != "" {
+		baseUrl = strings.TrimSuffix(env, "/")
+	} else if mirror := MirrorUrl(); mirror != "" {
+		return fmt.Sprintf("%s%s%s/apache-maven-%s-bin.tar.gz", mirror, mavenDistributionPath, version, version)
+	}
+
+	major := strings.SplitN(version, ".", 2)[0]
+	return fmt.Sprintf("%s/maven-%s/%s/binaries/apache-maven-%s-bin.tar.gz", baseUrl, major, version, version)
+}
+
+func (r *Runner) installMaven(appDir string, layer layers.Layer) (string, error) {
+	version, err := MavenVersion(appDir)
+	if err != nil {
+		return "", err
+	}
+
+	mavenUrl := MavenUrl(version)
+	mvn := filepath.Join(layer.Root, "bin", "mvn")
+
+	// releases never change, so the cache is used without checking the mirror
+	var cached Maven
+	if err := layer.ReadMetadata(&cached); err == nil && cached.IsReusable(version, mavenUrl, "") {
+		if _, err := os.Stat(mvn); err == nil {
+			fmt.Fprintf(r.Out, "Using cached Maven %s\n", version)
+			return mvn, cached.WriteMetadata(layer)
+		}
+	}
+
+	fmt.Fprintf(r.Out, "Installing Maven %s\n", version)
+
+	checksum, err := publishedChecksum(mavenUrl)
+	if err != nil {
+		return "", failedToInstallMaven(version, err)
+	}
+
+	installed, err := fetchMaven(r.Out, version, mavenUrl, checksum, layer)
+	if err != nil {
+		return "", failedToInstallMaven(version, err)
+	}
+
+	if err := installed.WriteMetadata(layer); err != nil {
+		return "", failedToInstallMaven(version, err)
+	}
+	return mvn, nil
+}
+
+// WriteMetadata records the installed Maven, keeping the layer in the cache so
+// later builds can reuse it.
+func (m Maven) WriteMetadata(layer layers.Layer) error {
+	return layer.WriteMetadata(m, layers.Cache)
+}
+
+// IsReusable is whether the cached Maven is the release that would be
+// installed, with the same checksum when one is given.
+func (m Maven) IsReusable(version, mavenUrl, checksum string) bool {
+	return m.Version == version && m.Url == mavenUrl && (checksum == "" || m.Checksum == checksum)
+}
+
+func fetchMaven(out io.Writer, version, mavenUrl, checksum string, layer layers.Layer) (Maven, error) {
+	sha256sum, err := fetchDistribution(out, mavenUrl, checksum, layer, func(archive *os.File) error {
+		return util.ExtractTarGz(archive, layer.Root, util.StripComponents(1))
+	})
+	if err != nil {
+		return Maven{}, err
+	}
+
+	return Maven{
+		Version:     version,
+		Url:         mavenUrl,
+		Sha256:      sha256sum,
+		Checksum:    checksum,
+		Stack:       os.Getenv("STACK"),
+		InstalledAt: time.Now().UTC().Truncate(time.Second),
+	}, nil
+}
+
+// fetchDistribution downloads a Maven distribution, verifies it against the
+// checksum, when there is one, and extracts it into the emptied layer. It
+// returns the SHA-256 of the download.
+func fetchDistribution(out io.Writer, url, checksum string, layer layers.Layer, extract func(archive *os.File) error) (string, error) {
+	archive, err := ioutil.TempFile("", "maven")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(archive.Name())
+	defer archive.Close()
+
+	sha256Hash := sha256.New()
+	hashes := []io.Writer{archive, sha256Hash}
+
+	algorithm := strings.SplitN(checksum, ":", 2)[0]
+	var checksumHash hash.Hash
+	if checksum == "" {
+		fmt.Fprintf(out, "WARNING: No checksum published for %s, Maven is installed without verification\n", url)
+	} else if newHash, ok := checksumAlgorithms[algorithm]; !ok {
+		return "", errors.New(fmt.Sprintf("unsupported checksum: %s", checksum))
+	} else {
+		checksumHash = newHash()
+		hashes = append(hashes, checksumHash)
+	}
+
+	if err := download(url, io.MultiWriter(hashes...)); err != nil {
+		return "", err
+	}
+
+	if checksumHash != nil {
+		if actual := algorithm + ":" + hex.EncodeToString(checksumHash.Sum(nil)); actual != checksum {
+			return "", invalidMavenChecksum(url, checksum, actual)
+		}
+	}
+
+	if err := util.ResetDir(layer.Root); err != nil {
+		return "", err
+	}
+
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	if err := extract(archive); err != nil {
+		return "", err
+	}
+
+	if err := os.Chmod(filepath.Join(layer.Root, "bin", "mvn"), 0755); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sha256Hash.Sum(nil)), nil
+}
+
+// publishedChecksum is the strongest checksum published for the download, as
+// an algorithm and hex digest such as "sha512:<digest>", or empty when there
+// is none.
+func publishedChecksum(mavenUrl string) (string, error) {
+	for _, algorithm := range []string{"sha512", "sha1"} {
+		digest, err := fetchChecksum(mavenUrl + "." + algorithm)
+		if err != nil {
+			return "", err
+		} else if digest != "" {
+			return algorithm + ":" + strings.ToLower(digest), nil
+		}
+	}
+	return "", nil
+}
+
+func download(url string, out io.Writer) error {
+	res, err := httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return errors.New(fmt.Sprintf("could not download %s: %s", url, res.Status))
+	}
+
+	_, err = io.Copy(out, res.Body)
+	return err
+}
+
+// fetchChecksum reads the digest published next to the tarball. An empty
+// digest is returned when there isn't one.
+func fetchChecksum(checksumUrl string) (string, error) {
+	res, err := httpClient.Get(checksumUrl)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusForbidden {
+		return "", nil
+	} else if res.StatusCode >= 300 {
+		return "", errors.New(fmt.Sprintf("could not download %s: %s", checksumUrl, res.Status))
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	if err != nil {
+		return "", err
+	}
+
+	fields := strings.Fields(string(body))
+	if len(fields) == 0 {
+		return "", errors.New(fmt.Sprintf("empty checksum at %s", checksumUrl))
+	}
+	return fields[0], nil
+}
@@ -0,0 +1,228 @@
+package maven_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/heroku/java-buildpack/maven"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestInstall(t *testing.T) {
+	spec.Run(t, "Install", testInstall, spec.Report(report.Terminal{}))
+}
+
+func testInstall(t *testing.T, when spec.G, it spec.S) {
+	var (
+		runner    *maven.Runner
+		layersDir layers.Layers
+		appDir    string
+		files     map[string][]byte
+		server    *fileServer
+	)
+
+	const tarballPath = "/maven-3/3.6.3/binaries/apache-maven-3.6.3-bin.tar.gz"
+
+	it.Before(func() {
+		layersDir = tempLayers(t)
+
+		var err error
+		appDir, err = ioutil.TempDir("", "app")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ioutil.WriteFile(filepath.Join(appDir, "system.properties"), []byte("maven.version=3.6.3"), 0644)
+
+		tarball := mavenTarball(t, "#!/bin/sh\necho Apache Maven 3.6.3\n")
+		sha512sum := sha512.Sum512(tarball)
+		files = map[string][]byte{
+			tarballPath:             tarball,
+			tarballPath + ".sha512": []byte(hex.EncodeToString(sha512sum[:])),
+		}
+		server = serveFiles(files)
+		os.Setenv("MAVEN_BASE_URL", server.URL)
+
+		runner = newRunner()
+	})
+
+	it.After(func() {
+		server.Close()
+		os.Unsetenv("MAVEN_BASE_URL")
+		os.RemoveAll(layersDir.Root)
+		os.RemoveAll(appDir)
+	})
+
+	when("#MavenVersion", func() {
+		it("should use maven.version from system.properties", func() {
+			if version, err := maven.MavenVersion(appDir); err != nil || version != "3.6.3" {
+				t.Fatalf(`Maven version did not match: got %s, %v`, version, err)
+			}
+		})
+
+		it("should use MAVEN_VERSION without a system.properties", func() {
+			os.Setenv("MAVEN_VERSION", "3.8.1")
+			defer os.Unsetenv("MAVEN_VERSION")
+
+			if version, err := maven.MavenVersion(fixture("app_with_pom")); err != nil || version != "3.8.1" {
+				t.Fatalf(`Maven version did not match: got %s, %v`, version, err)
+			}
+		})
+
+		it("should default to Maven 3.5.4", func() {
+			if version, err := maven.MavenVersion(fixture("app_with_pom")); err != nil || version != maven.DefaultMavenVersion {
+				t.Fatalf(`Maven version did not match: got %s, %v`, version, err)
+			}
+		})
+
+		it("should reject versions that aren't Maven releases", func() {
+			ioutil.WriteFile(filepath.Join(appDir, "system.properties"), []byte("maven.version=../../3.6.3"), 0644)
+
+			if _, err := maven.MavenVersion(appDir); err == nil || !strings.Contains(err.Error(), "Invalid Maven version") {
+				t.Fatalf(`expected an invalid version error: got %v`, err)
+			}
+		})
+	})
+
+	when("#Init", func() {
+		it("should install the app's Maven version", func() {
+			if err := runner.Init(appDir, layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			mvn := filepath.Join(layersDir.Layer("maven").Root, "bin", "mvn")
+			if runner.Command != mvn {
+				t.Fatalf(`runner command did not match: got %s, want %s`, runner.Command, mvn)
+			}
+
+			if fi, err := os.Stat(mvn); err != nil || fi.Mode().Perm()&0100 == 0 {
+				t.Fatalf(`mvn is not executable: %v`, err)
+			}
+
+			entries, err := maven.BomEntries(layersDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(entries) != 1 || entries[0].Version != "3.6.3" || entries[0].Metadata.Url != server.URL+tarballPath || entries[0].Metadata.Sha256 == "" {
+				t.Fatalf(`Maven layer metadata did not match: got %+v`, entries)
+			}
+		})
+
+		it("should reuse the cached Maven", func() {
+			for i := 0; i < 2; i++ {
+				if err := runner.Init(appDir, layersDir); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if server.Downloads != 1 {
+				t.Fatalf(`Maven was downloaded %d times`, server.Downloads)
+			}
+
+			mavenToml, _ := ioutil.ReadFile(layersDir.Layer("maven").Metadata)
+			if !strings.Contains(string(mavenToml), "cache = true") {
+				t.Fatalf(`Maven layer is not cached: \n%s`, mavenToml)
+			}
+		})
+
+		it("should reuse the cached Maven without checking the mirror", func() {
+			if err := runner.Init(appDir, layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			server.Close()
+
+			if err := runner.Init(appDir, layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			if server.Downloads != 1 {
+				t.Fatalf(`Maven was downloaded %d times`, server.Downloads)
+			}
+		})
+
+		it("should reinstall Maven when the app changes its version", func() {
+			if err := runner.Init(appDir, layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			ioutil.WriteFile(filepath.Join(appDir, "system.properties"), []byte("maven.version=3.6.2"), 0644)
+			if err := runner.Init(appDir, layersDir); err == nil {
+				t.Fatal("the cached Maven 3.6.3 was used for 3.6.2")
+			}
+		})
+
+		it("should warn when no checksum is published", func() {
+			delete(files, tarballPath+".sha512")
+			out := &bytes.Buffer{}
+			runner.Out = out
+
+			if err := runner.Init(appDir, layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(out.String(), "WARNING: No checksum published for "+server.URL+tarballPath) {
+				t.Fatalf(`expected a warning about the missing checksum: got %s`, out)
+			}
+		})
+
+		it("should verify the SHA-1 of releases without a SHA-512", func() {
+			delete(files, tarballPath+".sha512")
+			files[tarballPath+".sha1"] = []byte(strings.Repeat("0", 40))
+
+			if err := runner.Init(appDir, layersDir); err == nil || !strings.Contains(err.Error(), "expected sha1:") {
+				t.Fatalf(`expected a checksum error: got %v`, err)
+			}
+
+			sha1sum := sha1.Sum(files[tarballPath])
+			files[tarballPath+".sha1"] = []byte(hex.EncodeToString(sha1sum[:]) + "  apache-maven-3.6.3-bin.tar.gz")
+
+			if err := runner.Init(appDir, layersDir); err != nil {
+				t.Fatal(err)
+			}
+		})
+
+		it("should fail when the download does not match its checksum", func() {
+			files[tarballPath+".sha512"] = []byte(strings.Repeat("0", 128))
+
+			if err := runner.Init(appDir, layersDir); err == nil || !strings.Contains(err.Error(), "Failed to install Maven 3.6.3") {
+				t.Fatalf(`expected a checksum error: got %v`, err)
+			}
+
+			if _, err := os.Stat(filepath.Join(layersDir.Layer("maven").Root, "bin", "mvn")); !os.IsNotExist(err) {
+				t.Fatal("Maven was installed without a matching checksum")
+			}
+		})
+	})
+}
+
+func mavenTarball(t *testing.T, mvn string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for name, body := range map[string]string{
+		"apache-maven-3.6.3/bin/mvn":           mvn,
+		"apache-maven-3.6.3/conf/settings.xml": "<settings/>",
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(body))
+	}
+
+	tw.Close()
+	gz.Close()
+	return buf.Bytes()
+}
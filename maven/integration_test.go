@@ -45,8 +45,6 @@ func testIntegrationMaven(t *testing.T, when spec.G, it spec.S) {
 
 	it.Before(func() {
 		os.Setenv("STACK", "heroku-18")
-		wd, _ := os.Getwd()
-		os.Setenv("PATH", fmt.Sprintf("%s:%s", os.Getenv("PATH"), filepath.Join(wd, "..", "bin")))
 
 		root, err := ioutil.TempDir("", "layers")
 		if err != nil {
@@ -139,7 +137,6 @@ func testIntegrationMaven(t *testing.T, when spec.G, it spec.S) {
 func installGlobalJdk(installDir string) error {
 	os.Setenv("STACK", "heroku-18")
 	wd, _ := os.Getwd()
-	os.Setenv("PATH", fmt.Sprintf("%s:%s", os.Getenv("PATH"), filepath.Join(wd, "..", "bin")))
 
 	layersRoot, err := ioutil.TempDir("", "jdk-cache")
 	if err != nil {
@@ -20,6 +20,8 @@ type Runner struct {
 	Command  string
 	Options  []string
 	Goals    []string
+	// JavaHome is the JDK Maven runs with, instead of the JAVA_HOME of the environment
+	JavaHome string
 }
 
 func (r *Runner) Run(appDir, defaultGoals string, options []string, layersDir layers.Layers) error {
@@ -31,6 +33,15 @@ func (r *Runner) Run(appDir, defaultGoals string, options []string, layersDir la
 		return err
 	}
 
+	if r.hasMavenWrapper(appDir) {
+		mvn, err := r.fetchWrapperDistribution(appDir, layersDir.Layer(WrapperLayerName))
+		if err != nil {
+			return err
+		} else if mvn != "" {
+			r.Command = mvn
+		}
+	}
+
 	m2Dir, err := r.createMavenRepoDir(appDir, layersDir)
 	if err != nil {
 		return err
@@ -41,7 +52,7 @@ func (r *Runner) Run(appDir, defaultGoals string, options []string, layersDir la
 
 	fmt.Printf("$ mvn %s %s\n", strings.Join(r.Options, " "), strings.Join(r.Goals, " "))
 	cmd := exec.Command(r.Command, mavenArgs...)
-	cmd.Env = os.Environ()
+	cmd.Env = r.environ()
 	cmd.Dir = appDir
 	cmd.Stdin = bytes.NewBuffer(r.In)
 	cmd.Stdout = r.Out
@@ -72,14 +83,37 @@ func (r *Runner) Init(appDir string, layersDir layers.Layers) error {
 	return nil
 }
 
+// environ is the environment Maven runs in, with the runner's JDK first on
+// the PATH when it has one. The Maven wrapper downloads through the mirror
+// when there is one.
+func (r *Runner) environ() []string {
+	env := os.Environ()
+	if mirror := MirrorUrl(); mirror != "" {
+		env = append(env, "MVNW_REPOURL="+mirror)
+	}
+
+	if r.JavaHome == "" {
+		return env
+	}
+
+	var overridden []string
+	for _, v := range env {
+		if !strings.HasPrefix(v, "JAVA_HOME=") && !strings.HasPrefix(v, "PATH=") {
+			overridden = append(overridden, v)
+		}
+	}
+	return append(overridden,
+		"JAVA_HOME="+r.JavaHome,
+		"PATH="+filepath.Join(r.JavaHome, "bin")+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
 func (r *Runner) resolveMavenCommand(appDir string, layersDir layers.Layers) (string, error) {
 	if r.hasMavenWrapper(appDir) {
 		mvn := filepath.Join(appDir, "mvnw")
 		os.Chmod(mvn, 0774)
 		return mvn, nil
 	} else {
-		mavenCacheLayer := layersDir.Layer("maven")
-		mvn, err := r.installMaven(mavenCacheLayer.Root)
+		mvn, err := r.installMaven(appDir, layersDir.Layer("maven"))
 		if err != nil {
 			return "", err
 		}
@@ -87,15 +121,6 @@ func (r *Runner) resolveMavenCommand(appDir string, layersDir layers.Layers) (st
 	}
 }
 
-func (r *Runner) installMaven(installDir string) (string, error) {
-	cmd := exec.Command(filepath.Join("maven-installer"), installDir)
-	cmd.Env = os.Environ()
-	cmd.Stdout = r.Out
-	cmd.Stderr = r.Err
-
-	return filepath.Join(installDir, "bin", "mvn"), cmd.Run()
-}
-
 func (r *Runner) constructGoals(defaultGoals []string) []string {
 	if goals, isSet := os.LookupEnv("MAVEN_CUSTOM_GOALS"); isSet {
 		return parseGoals(goals)
@@ -118,6 +143,13 @@ func (r *Runner) constructOptions(appDir string) ([]string, error) {
 
 	opts = append(opts, settingsOpt...)
 
+	mirrorOpts, err := r.constructMirrorOpts()
+	if err != nil {
+		return []string{}, err
+	}
+
+	opts = append(opts, mirrorOpts...)
+
 	if customOpts, isSet := os.LookupEnv("MAVEN_CUSTOM_OPTS"); isSet {
 		opts = append(opts, parseGoals(customOpts)...)
 	}
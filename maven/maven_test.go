@@ -3,6 +3,8 @@ package maven_test
 import (
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
@@ -150,6 +152,49 @@ func testMaven(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 	})
+
+	when("#Run", func() {
+		var home string
+
+		it.Before(func() {
+			var err error
+			appDir, err = ioutil.TempDir("", "app")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// a wrapper that records the JDK it was run with
+			os.MkdirAll(filepath.Join(appDir, ".mvn", "wrapper"), 0755)
+			ioutil.WriteFile(filepath.Join(appDir, ".mvn", "wrapper", "maven-wrapper.jar"), []byte{}, 0644)
+			ioutil.WriteFile(filepath.Join(appDir, ".mvn", "wrapper", "maven-wrapper.properties"), []byte{}, 0644)
+			ioutil.WriteFile(filepath.Join(appDir, "mvnw"), []byte("#!/bin/sh\necho \"$JAVA_HOME $(which java)\" > java_home.txt\n"), 0755)
+
+			home = os.Getenv("HOME")
+			os.Setenv("HOME", appDir)
+		})
+
+		it.After(func() {
+			os.Setenv("HOME", home)
+			os.RemoveAll(appDir)
+		})
+
+		it("should run Maven with the runner's JDK", func() {
+			javaHome := filepath.Join(appDir, "jdk")
+			os.MkdirAll(filepath.Join(javaHome, "bin"), 0755)
+			ioutil.WriteFile(filepath.Join(javaHome, "bin", "java"), []byte("#!/bin/sh\n"), 0755)
+			runner.JavaHome = javaHome
+
+			if err := runner.Run(appDir, "install", []string{}, layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			out, _ := ioutil.ReadFile(filepath.Join(appDir, "java_home.txt"))
+			expected := fmt.Sprintf("%s %s", javaHome, filepath.Join(javaHome, "bin", "java"))
+			if strings.TrimSpace(string(out)) != expected {
+				t.Fatalf(`Maven JDK did not match: got %s, want %s`, out, expected)
+			}
+		})
+	})
 }
 
 func hasOption(opts []string, opt string) bool {
@@ -165,3 +210,48 @@ func fixture(name string) string {
 	wd, _ := os.Getwd()
 	return filepath.Join(wd, "..", "test", "fixtures", name)
 }
+
+// fileServer serves files at any path that ends with their name, as a mirror
+// under a prefix does, and records the distributions downloaded.
+type fileServer struct {
+	*httptest.Server
+	Files     map[string][]byte
+	Downloads int
+	Requested string
+}
+
+func serveFiles(files map[string][]byte) *fileServer {
+	s := &fileServer{Files: files}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for path, body := range s.Files {
+			if !strings.HasSuffix(r.URL.Path, path) {
+				continue
+			}
+
+			if ext := filepath.Ext(path); ext == ".gz" || ext == ".zip" {
+				s.Downloads++
+				s.Requested = r.URL.Path
+			}
+			w.Write(body)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	return s
+}
+
+func newRunner() *maven.Runner {
+	return &maven.Runner{
+		In:  []byte{},
+		Out: ioutil.Discard,
+		Err: ioutil.Discard,
+	}
+}
+
+func tempLayers(t *testing.T) layers.Layers {
+	root, err := ioutil.TempDir("", "layers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return layers.NewLayers(root, logger.DefaultLogger())
+}
@@ -0,0 +1,84 @@
+package maven
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// MirrorId is the id of the <mirror> added to the settings for
+	// MAVEN_MIRROR_URL
+	MirrorId = "maven-mirror"
+	// mavenDistributionPath is where Maven distributions are in a Maven
+	// repository, such as Maven Central or a proxy of it
+	mavenDistributionPath = "/org/apache/maven/apache-maven/"
+)
+
+// MirrorUrl is the Maven repository set by MAVEN_MIRROR_URL that Maven, and the
+// Maven distributions, are downloaded through instead of Maven Central.
+func MirrorUrl() string {
+	return strings.TrimSuffix(os.Getenv("MAVEN_MIRROR_URL"), "/")
+}
+
+// mirrorDistributionUrl rewrites the URL of a Maven distribution in a Maven
+// repository to the same distribution in the mirror. URLs that aren't in a
+// Maven repository are kept.
+func mirrorDistributionUrl(url string) string {
+	mirror := MirrorUrl()
+	if mirror == "" {
+		return url
+	}
+
+	if i := strings.Index(url, mavenDistributionPath); i >= 0 {
+		return mirror + url[i:]
+	}
+	return url
+}
+
+// constructMirrorOpts points Maven at global settings with a mirror of every
+// repository, so the app's own settings, which Maven merges over them, are
+// kept.
+func (r *Runner) constructMirrorOpts() ([]string, error) {
+	mirror := MirrorUrl()
+	if mirror == "" {
+		return nil, nil
+	}
+
+	settings, err := mirrorSettings(mirror)
+	if err != nil {
+		return nil, failedToWriteMirrorSettings(err)
+	}
+
+	settingsXml := filepath.Join(os.TempDir(), "mirror-settings.xml")
+	if err := ioutil.WriteFile(settingsXml, settings, 0644); err != nil {
+		return nil, failedToWriteMirrorSettings(err)
+	}
+
+	fmt.Fprintf(r.Out, "Using Maven mirror %s from MAVEN_MIRROR_URL\n", mirror)
+	return []string{"-gs", settingsXml}, nil
+}
+
+func mirrorSettings(mirror string) ([]byte, error) {
+	var url bytes.Buffer
+	if err := xml.EscapeText(&url, []byte(mirror)); err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<settings xmlns="http://maven.apache.org/SETTINGS/1.0.0">
+  <mirrors>
+    <mirror>
+      <id>%s</id>
+      <name>MAVEN_MIRROR_URL</name>
+      <url>%s</url>
+      <mirrorOf>*</mirrorOf>
+    </mirror>
+  </mirrors>
+</settings>
+`, MirrorId, url.String())), nil
+}
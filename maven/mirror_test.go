@@ -0,0 +1,111 @@
+package maven_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/buildpack/libbuildpack/logger"
+	"github.com/heroku/java-buildpack/maven"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestMirror(t *testing.T) {
+	spec.Run(t, "Mirror", testMirror, spec.Report(report.Terminal{}))
+}
+
+func testMirror(t *testing.T, when spec.G, it spec.S) {
+	var (
+		runner    *maven.Runner
+		layersDir layers.Layers
+	)
+
+	it.Before(func() {
+		root, err := ioutil.TempDir("", "layers")
+		if err != nil {
+			t.Fatal(err)
+		}
+		layersDir = layers.NewLayers(root, logger.DefaultLogger())
+
+		os.Setenv("MAVEN_MIRROR_URL", "https://nexus.example.com/repository/maven-public/")
+
+		runner = &maven.Runner{
+			In:  []byte{},
+			Out: ioutil.Discard,
+			Err: ioutil.Discard,
+		}
+	})
+
+	it.After(func() {
+		os.Unsetenv("MAVEN_MIRROR_URL")
+		os.RemoveAll(layersDir.Root)
+	})
+
+	when("#Init", func() {
+		it("should add the mirror to the global settings and keep the app's settings", func() {
+			if err := runner.Init(fixture("app_with_settings"), layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			if !hasOption(runner.Options, "-s") || !hasOption(runner.Options, "settings.xml") {
+				t.Fatalf(`runner options do not use the app's settings: \n%s`, runner.Options)
+			}
+
+			var globalSettings string
+			for i, opt := range runner.Options {
+				if opt == "-gs" && i+1 < len(runner.Options) {
+					globalSettings = runner.Options[i+1]
+				}
+			}
+
+			settings, err := ioutil.ReadFile(globalSettings)
+			if err != nil {
+				t.Fatalf(`runner options do not use global settings: \n%s`, runner.Options)
+			}
+
+			for _, expected := range []string{
+				"<id>maven-mirror</id>",
+				"<url>https://nexus.example.com/repository/maven-public</url>",
+				"<mirrorOf>*</mirrorOf>",
+			} {
+				if !strings.Contains(string(settings), expected) {
+					t.Fatalf(`Expected to find "%s" in: %s`, expected, settings)
+				}
+			}
+		})
+
+		it("should not add a mirror without MAVEN_MIRROR_URL", func() {
+			os.Unsetenv("MAVEN_MIRROR_URL")
+
+			if err := runner.Init(fixture("app_with_wrapper"), layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			if hasOption(runner.Options, "-gs") {
+				t.Fatalf(`runner options use global settings: \n%s`, runner.Options)
+			}
+		})
+	})
+
+	when("#MavenUrl", func() {
+		it("should download Maven from the mirror", func() {
+			expected := "https://nexus.example.com/repository/maven-public/org/apache/maven/apache-maven/3.6.3/apache-maven-3.6.3-bin.tar.gz"
+			if url := maven.MavenUrl("3.6.3"); url != expected {
+				t.Fatalf(`Maven URL did not match: got %s, want %s`, url, expected)
+			}
+		})
+
+		it("should prefer MAVEN_BASE_URL", func() {
+			os.Setenv("MAVEN_BASE_URL", "https://archive.example.com/maven")
+			defer os.Unsetenv("MAVEN_BASE_URL")
+
+			expected := "https://archive.example.com/maven/maven-3/3.6.3/binaries/apache-maven-3.6.3-bin.tar.gz"
+			if url := maven.MavenUrl("3.6.3"); url != expected {
+				t.Fatalf(`Maven URL did not match: got %s, want %s`, url, expected)
+			}
+		})
+	})
+}
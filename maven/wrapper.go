@@ -0,0 +1,121 @@
+package maven
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/heroku/java-buildpack/util"
+)
+
+const (
+	// WrapperLayerName is the cached layer the Maven wrapper's distribution
+	// is fetched into
+	WrapperLayerName = "maven_wrapper"
+)
+
+var (
+	wrapperDistributionVersion = regexp.MustCompile(`apache-maven-([^/]+)-bin\.zip$`)
+)
+
+// WrapperDistribution is the Maven distribution the app's Maven wrapper is set
+// up to download, from .mvn/wrapper/maven-wrapper.properties.
+type WrapperDistribution struct {
+	Url string
+	// Sha256 is the distributionSha256Sum the download is verified with, when
+	// the wrapper has one
+	Sha256 string
+}
+
+// ReadWrapperDistribution reads the distribution from the wrapper's
+// properties. Its Url is empty when the wrapper doesn't set distributionUrl.
+func ReadWrapperDistribution(appDir string) (WrapperDistribution, error) {
+	props, err := util.ReadPropertiesFile(filepath.Join(appDir, ".mvn", "wrapper", "maven-wrapper.properties"))
+	if err != nil {
+		return WrapperDistribution{}, err
+	}
+
+	return WrapperDistribution{
+		Url:    unescapeProperty(props["distributionUrl"]),
+		Sha256: strings.ToLower(props["distributionSha256Sum"]),
+	}, nil
+}
+
+// Version is the Maven version of the distribution, or empty when its URL
+// isn't an Apache Maven binary zip.
+func (d WrapperDistribution) Version() string {
+	if match := wrapperDistributionVersion.FindStringSubmatch(d.Url); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+func (d WrapperDistribution) checksum() string {
+	if d.Sha256 == "" {
+		return ""
+	}
+	return "sha256:" + d.Sha256
+}
+
+// fetchWrapperDistribution fetches the wrapper's distribution into the cached
+// layer, so the build doesn't download it into ~/.m2/wrapper every time, and
+// returns the mvn it should run instead of mvnw. mvn reads the project's .mvn
+// directory just like the wrapper does. It returns an empty command when the
+// wrapper has no distribution to fetch.
+func (r *Runner) fetchWrapperDistribution(appDir string, layer layers.Layer) (string, error) {
+	distribution, err := ReadWrapperDistribution(appDir)
+	if err != nil || distribution.Url == "" {
+		return "", nil
+	}
+
+	distribution.Url = mirrorDistributionUrl(distribution.Url)
+	mvn := filepath.Join(layer.Root, "bin", "mvn")
+	version := distribution.Version()
+
+	var cached Maven
+	if err := layer.ReadMetadata(&cached); err == nil && cached.IsReusable(version, distribution.Url, distribution.checksum()) {
+		if _, err := os.Stat(mvn); err == nil {
+			fmt.Fprintf(r.Out, "Using cached Maven wrapper distribution %s\n", distribution.Url)
+			return mvn, cached.WriteMetadata(layer)
+		}
+	}
+
+	fmt.Fprintf(r.Out, "Downloading Maven wrapper distribution %s\n", distribution.Url)
+
+	sha256sum, err := fetchDistribution(r.Out, distribution.Url, distribution.checksum(), layer, func(archive *os.File) error {
+		return util.ExtractZip(archive.Name(), layer.Root, util.StripComponents(1))
+	})
+	if err != nil {
+		return "", failedToFetchWrapperDistribution(distribution.Url, err)
+	}
+
+	installed := Maven{
+		Version:     version,
+		Url:         distribution.Url,
+		Sha256:      sha256sum,
+		Checksum:    distribution.checksum(),
+		Stack:       os.Getenv("STACK"),
+		InstalledAt: time.Now().UTC().Truncate(time.Second),
+	}
+	if err := installed.WriteMetadata(layer); err != nil {
+		return "", failedToFetchWrapperDistribution(distribution.Url, err)
+	}
+	return mvn, nil
+}
+
+// unescapeProperty removes the backslashes that escape characters such as :
+// and = in a properties file, as in distributionUrl=https\://repo.maven.apache.org
+func unescapeProperty(value string) string {
+	var unescaped strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] == '\\' && i+1 < len(value) {
+			i++
+		}
+		unescaped.WriteByte(value[i])
+	}
+	return unescaped.String()
+}
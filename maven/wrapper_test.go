@@ -0,0 +1,168 @@
+package maven_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/buildpack/libbuildpack/layers"
+	"github.com/heroku/java-buildpack/maven"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestWrapper(t *testing.T) {
+	spec.Run(t, "Wrapper", testWrapper, spec.Report(report.Terminal{}))
+}
+
+func testWrapper(t *testing.T, when spec.G, it spec.S) {
+	var (
+		runner    *maven.Runner
+		layersDir layers.Layers
+		appDir    string
+		home      string
+		zipFile   []byte
+		server    *fileServer
+	)
+
+	const distributionPath = "/org/apache/maven/apache-maven/3.6.3/apache-maven-3.6.3-bin.zip"
+
+	it.Before(func() {
+		layersDir = tempLayers(t)
+
+		var err error
+		appDir, err = ioutil.TempDir("", "app")
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.MkdirAll(filepath.Join(appDir, ".mvn", "wrapper"), 0755)
+		ioutil.WriteFile(filepath.Join(appDir, ".mvn", "wrapper", "maven-wrapper.jar"), []byte{}, 0644)
+		ioutil.WriteFile(filepath.Join(appDir, "mvnw"), []byte("#!/bin/sh\nexit 1\n"), 0755)
+
+		home = os.Getenv("HOME")
+		os.Setenv("HOME", appDir)
+
+		zipFile = distributionZip(t)
+		server = serveFiles(map[string][]byte{distributionPath: zipFile})
+
+		runner = newRunner()
+	})
+
+	it.After(func() {
+		server.Close()
+		os.Setenv("HOME", home)
+		os.RemoveAll(layersDir.Root)
+		os.RemoveAll(appDir)
+	})
+
+	wrapperProperties := func(props string) {
+		if err := ioutil.WriteFile(filepath.Join(appDir, ".mvn", "wrapper", "maven-wrapper.properties"), []byte(props), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	when("#ReadWrapperDistribution", func() {
+		it("should read the escaped distribution URL and checksum", func() {
+			wrapperProperties("distributionUrl=https\\://repo.maven.apache.org/maven2/org/apache/maven/apache-maven/3.6.3/apache-maven-3.6.3-bin.zip\ndistributionSha256Sum=ABC123\n")
+
+			distribution, err := maven.ReadWrapperDistribution(appDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if distribution.Url != "https://repo.maven.apache.org/maven2/org/apache/maven/apache-maven/3.6.3/apache-maven-3.6.3-bin.zip" || distribution.Sha256 != "abc123" {
+				t.Fatalf(`wrapper distribution did not match: got %+v`, distribution)
+			}
+
+			if distribution.Version() != "3.6.3" {
+				t.Fatalf(`wrapper distribution version did not match: got %s`, distribution.Version())
+			}
+		})
+	})
+
+	when("#Run", func() {
+		it("should run the cached wrapper distribution instead of mvnw", func() {
+			sha256sum := sha256.Sum256(zipFile)
+			wrapperProperties(fmt.Sprintf("distributionUrl=%s%s\ndistributionSha256Sum=%s\n", server.URL, distributionPath, hex.EncodeToString(sha256sum[:])))
+
+			for i := 0; i < 2; i++ {
+				if err := runner.Run(appDir, "install", []string{}, layersDir); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if server.Downloads != 1 {
+				t.Fatalf(`wrapper distribution was downloaded %d times`, server.Downloads)
+			}
+
+			if mvn := filepath.Join(layersDir.Layer(maven.WrapperLayerName).Root, "bin", "mvn"); runner.Command != mvn {
+				t.Fatalf(`runner command did not match: got %s, want %s`, runner.Command, mvn)
+			}
+
+			if args, _ := ioutil.ReadFile(filepath.Join(appDir, "mvn_args.txt")); !strings.Contains(string(args), "install") {
+				t.Fatalf(`wrapper distribution did not run the goals: %s`, args)
+			}
+
+			entries, err := maven.BomEntries(layersDir)
+			if err != nil || len(entries) != 1 || entries[0].Version != "3.6.3" || entries[0].Metadata.Layer != maven.WrapperLayerName {
+				t.Fatalf(`BOM entries did not match: got %+v, %v`, entries, err)
+			}
+		})
+
+		it("should download the distribution through MAVEN_MIRROR_URL", func() {
+			os.Setenv("MAVEN_MIRROR_URL", server.URL+"/repository/")
+			defer os.Unsetenv("MAVEN_MIRROR_URL")
+			wrapperProperties("distributionUrl=https\\://repo.maven.apache.org/maven2/org/apache/maven/apache-maven/3.6.3/apache-maven-3.6.3-bin.zip\n")
+
+			if err := runner.Run(appDir, "install", []string{}, layersDir); err != nil {
+				t.Fatal(err)
+			}
+
+			if server.Requested != "/repository"+distributionPath {
+				t.Fatalf(`distribution was not downloaded from the mirror: got %s`, server.Requested)
+			}
+		})
+
+		it("should fail when the distribution does not match distributionSha256Sum", func() {
+			wrapperProperties(fmt.Sprintf("distributionUrl=%s%s\ndistributionSha256Sum=%s\n", server.URL, distributionPath, strings.Repeat("0", 64)))
+
+			err := runner.Run(appDir, "install", []string{}, layersDir)
+			if err == nil || !strings.Contains(err.Error(), "Failed to download the Maven wrapper distribution") {
+				t.Fatalf(`expected a checksum error: got %v`, err)
+			}
+		})
+
+		it("should run mvnw when the wrapper has no distributionUrl", func() {
+			wrapperProperties("")
+
+			if err := runner.Run(appDir, "install", []string{}, layersDir); err == nil || !strings.HasSuffix(runner.Command, "mvnw") {
+				t.Fatalf(`runner did not run mvnw: got %s, %v`, runner.Command, err)
+			}
+		})
+	})
+}
+
+func distributionZip(t *testing.T) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	header := &zip.FileHeader{Name: "apache-maven-3.6.3/bin/mvn", Method: zip.Deflate}
+	header.SetMode(0755)
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("#!/bin/sh\necho \"$@\" > mvn_args.txt\n"))
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
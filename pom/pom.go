@@ -0,0 +1,267 @@
+package pom
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxParents bounds the chain of local parents that is read, in case
+	// their relativePaths form a cycle
+	maxParents = 10
+	// maxInterpolations bounds the nesting of property references, in case
+	// properties refer to each other
+	maxInterpolations = 10
+
+	springBootGroupId = "org.springframework.boot"
+)
+
+var (
+	propertyPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+)
+
+// Project is the model of a pom.xml, merged with the parents it can find in
+// the app, that answers questions about the build without running Maven.
+type Project struct {
+	Parent         Parent     `xml:"parent"`
+	GroupId        string     `xml:"groupId"`
+	ArtifactId     string     `xml:"artifactId"`
+	Version        string     `xml:"version"`
+	Packaging      string     `xml:"packaging"`
+	Properties     Properties `xml:"properties"`
+	Modules        []string   `xml:"modules>module"`
+	Plugins        []Plugin   `xml:"build>plugins>plugin"`
+	ManagedPlugins []Plugin   `xml:"build>pluginManagement>plugins>plugin"`
+	// ancestors are the parents of the parents read from the app
+	ancestors []Parent
+}
+
+type Parent struct {
+	GroupId      string  `xml:"groupId"`
+	ArtifactId   string  `xml:"artifactId"`
+	Version      string  `xml:"version"`
+	RelativePath *string `xml:"relativePath"`
+}
+
+type Plugin struct {
+	GroupId       string     `xml:"groupId"`
+	ArtifactId    string     `xml:"artifactId"`
+	Version       string     `xml:"version"`
+	Configuration Properties `xml:"configuration"`
+}
+
+// Properties are the text of an element's children, such as the <properties>
+// of a pom.xml or the <configuration> of a plugin.
+type Properties map[string]string
+
+func (p *Properties) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	*p = Properties{}
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			var value string
+			if err := d.DecodeElement(&value, &t); err != nil {
+				return err
+			}
+			(*p)[t.Name.Local] = strings.TrimSpace(value)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// Read reads the app's pom.xml. Its packaging, modules and plugins are
+// interpolated, and it inherits the properties and coordinates of the parents
+// found at their relativePath.
+func Read(appDir string) (Project, error) {
+	project, err := ReadFile(filepath.Join(appDir, "pom.xml"))
+	if err != nil {
+		return Project{}, err
+	}
+
+	if err := project.inheritParents(appDir); err != nil {
+		return Project{}, err
+	}
+
+	project.interpolate()
+	return project, nil
+}
+
+// ReadFile parses a single pom.xml without its parents or interpolation.
+func ReadFile(path string) (Project, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Project{}, err
+	}
+	defer f.Close()
+
+	var project Project
+	if err := xml.NewDecoder(f).Decode(&project); err != nil {
+		return Project{}, errors.New(fmt.Sprintf("could not parse %s: %s", path, err))
+	}
+
+	if project.Properties == nil {
+		project.Properties = Properties{}
+	}
+	return project, nil
+}
+
+func (p *Project) inheritParents(dir string) error {
+	parent := p.Parent
+	for n := 0; n < maxParents && parent.ArtifactId != ""; n++ {
+		relativePath := "../pom.xml"
+		if parent.RelativePath != nil {
+			relativePath = *parent.RelativePath
+		}
+		if relativePath == "" {
+			return nil
+		}
+
+		path := filepath.Join(dir, relativePath)
+		if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+			path = filepath.Join(path, "pom.xml")
+		}
+
+		parentProject, err := ReadFile(path)
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		} else if parentProject.ArtifactId != parent.ArtifactId {
+			return nil
+		}
+
+		p.inherit(parentProject)
+		dir, parent = filepath.Dir(path), parentProject.Parent
+		if parent.ArtifactId != "" {
+			p.ancestors = append(p.ancestors, parent)
+		}
+	}
+	return nil
+}
+
+func (p *Project) inherit(parent Project) {
+	if p.GroupId == "" {
+		p.GroupId = parent.GroupId
+	}
+	if p.Version == "" {
+		p.Version = parent.Version
+	}
+	for name, value := range parent.Properties {
+		if _, ok := p.Properties[name]; !ok {
+			p.Properties[name] = value
+		}
+	}
+	p.ManagedPlugins = append(p.ManagedPlugins, parent.ManagedPlugins...)
+	p.Plugins = append(p.Plugins, parent.Plugins...)
+}
+
+func (p *Project) interpolate() {
+	if p.Packaging = p.Interpolate(p.Packaging); p.Packaging == "" {
+		p.Packaging = "jar"
+	}
+
+	for i := range p.Modules {
+		p.Modules[i] = p.Interpolate(p.Modules[i])
+	}
+
+	for _, plugins := range [][]Plugin{p.Plugins, p.ManagedPlugins} {
+		for i := range plugins {
+			plugins[i].Version = p.Interpolate(plugins[i].Version)
+			for name, value := range plugins[i].Configuration {
+				plugins[i].Configuration[name] = p.Interpolate(value)
+			}
+		}
+	}
+}
+
+// Interpolate replaces ${property} references with the project's properties,
+// or its project.* coordinates. References to unknown properties are kept.
+func (p Project) Interpolate(value string) string {
+	for n := 0; n < maxInterpolations && propertyPattern.MatchString(value); n++ {
+		interpolated := propertyPattern.ReplaceAllStringFunc(value, func(ref string) string {
+			if v, ok := p.property(propertyPattern.FindStringSubmatch(ref)[1]); ok {
+				return v
+			}
+			return ref
+		})
+
+		if interpolated == value {
+			break
+		}
+		value = interpolated
+	}
+	return strings.TrimSpace(value)
+}
+
+// Property is the interpolated value of one of the project's properties.
+func (p Project) Property(name string) string {
+	if v, ok := p.property(name); ok {
+		return p.Interpolate(v)
+	}
+	return ""
+}
+
+func (p Project) property(name string) (string, bool) {
+	switch name {
+	case "project.groupId", "pom.groupId":
+		return p.GroupId, true
+	case "project.artifactId", "pom.artifactId":
+		return p.ArtifactId, true
+	case "project.version", "pom.version":
+		return p.Version, true
+	case "project.parent.version":
+		return p.Parent.Version, true
+	}
+
+	v, ok := p.Properties[name]
+	return v, ok
+}
+
+// FindPlugins are the build plugins, including managed plugins, with the
+// artifactId.
+func (p Project) FindPlugins(artifactId string) []Plugin {
+	var found []Plugin
+	for _, plugin := range append(append([]Plugin{}, p.Plugins...), p.ManagedPlugins...) {
+		if plugin.ArtifactId == artifactId {
+			found = append(found, plugin)
+		}
+	}
+	return found
+}
+
+// HasPlugin is whether the build runs a plugin, which it doesn't when the
+// plugin is only managed.
+func (p Project) HasPlugin(artifactId string) bool {
+	for _, plugin := range p.Plugins {
+		if plugin.ArtifactId == artifactId {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSpringBoot is whether the project is a Spring Boot app, either by
+// inheriting from the Spring Boot parent or by building with its plugin.
+func (p Project) IsSpringBoot() bool {
+	for _, parent := range append([]Parent{p.Parent}, p.ancestors...) {
+		if parent.GroupId == springBootGroupId && parent.ArtifactId == "spring-boot-starter-parent" {
+			return true
+		}
+	}
+	return p.HasPlugin("spring-boot-maven-plugin")
+}
+
+func (p Project) IsWar() bool {
+	return p.Packaging == "war"
+}
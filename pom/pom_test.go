@@ -0,0 +1,249 @@
+package pom_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/heroku/java-buildpack/pom"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestPom(t *testing.T) {
+	spec.Run(t, "Pom", testPom, spec.Report(report.Terminal{}))
+}
+
+func testPom(t *testing.T, when spec.G, it spec.S) {
+	var rootDir string
+
+	it.Before(func() {
+		var err error
+		rootDir, err = ioutil.TempDir("", "project")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	it.After(func() {
+		os.RemoveAll(rootDir)
+	})
+
+	writePom := func(dir, xml string) string {
+		dir = filepath.Join(rootDir, dir)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "pom.xml"), []byte(xml), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+
+	when("#Read", func() {
+		it("should read the packaging, modules and plugins", func() {
+			appDir := writePom("app", `<project>
+  <groupId>com.example</groupId>
+  <artifactId>parent</artifactId>
+  <version>1.0.0</version>
+  <packaging>${packaging.type}</packaging>
+  <properties>
+    <packaging.type>pom</packaging.type>
+    <compiler.version>3.8.1</compiler.version>
+    <java.release>${java.major}</java.release>
+    <java.major>17</java.major>
+  </properties>
+  <modules>
+    <module>api</module>
+    <module>web-${project.version}</module>
+  </modules>
+  <build>
+    <plugins>
+      <plugin>
+        <artifactId>maven-compiler-plugin</artifactId>
+        <version>${compiler.version}</version>
+        <configuration>
+          <release>${java.release}</release>
+        </configuration>
+      </plugin>
+    </plugins>
+  </build>
+</project>`)
+
+			project, err := pom.Read(appDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if project.Packaging != "pom" {
+				t.Fatalf(`packaging did not match: got %s`, project.Packaging)
+			}
+
+			if len(project.Modules) != 2 || project.Modules[1] != "web-1.0.0" {
+				t.Fatalf(`modules did not match: got %v`, project.Modules)
+			}
+
+			plugins := project.FindPlugins("maven-compiler-plugin")
+			if len(plugins) != 1 || plugins[0].Version != "3.8.1" || plugins[0].Configuration["release"] != "17" {
+				t.Fatalf(`plugins did not match: got %+v`, plugins)
+			}
+		})
+
+		it("should default to jar packaging", func() {
+			appDir := writePom("app", `<project><artifactId>app</artifactId></project>`)
+
+			project, err := pom.Read(appDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if project.Packaging != "jar" || project.IsWar() {
+				t.Fatalf(`packaging did not match: got %s`, project.Packaging)
+			}
+		})
+
+		it("should keep references to unknown or cyclic properties", func() {
+			appDir := writePom("app", `<project>
+  <properties>
+    <a>${b}</a>
+    <b>${a}</b>
+    <c>${undefined}</c>
+  </properties>
+</project>`)
+
+			project, err := pom.Read(appDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c := project.Property("c"); c != "${undefined}" {
+				t.Fatalf(`property did not match: got %s`, c)
+			}
+
+			if a := project.Property("a"); a != "${a}" && a != "${b}" {
+				t.Fatalf(`cyclic property did not match: got %s`, a)
+			}
+		})
+
+		it("should inherit from parents at their relativePath", func() {
+			writePom("", `<project>
+  <parent>
+    <groupId>org.springframework.boot</groupId>
+    <artifactId>spring-boot-starter-parent</artifactId>
+    <version>2.7.0</version>
+  </parent>
+  <groupId>com.example</groupId>
+  <artifactId>platform</artifactId>
+  <version>2.0.0</version>
+  <properties>
+    <java.version>11</java.version>
+    <app.name>platform</app.name>
+  </properties>
+</project>`)
+
+			appDir := writePom("app", `<project>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>platform</artifactId>
+    <version>2.0.0</version>
+  </parent>
+  <artifactId>app</artifactId>
+  <packaging>war</packaging>
+  <properties>
+    <app.name>app</app.name>
+    <artifact>${app.name}-${project.version}</artifact>
+  </properties>
+</project>`)
+
+			project, err := pom.Read(appDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if project.GroupId != "com.example" || project.Version != "2.0.0" {
+				t.Fatalf(`coordinates were not inherited: got %s:%s`, project.GroupId, project.Version)
+			}
+
+			if project.Property("java.version") != "11" || project.Property("artifact") != "app-2.0.0" {
+				t.Fatalf(`properties were not inherited: got %v`, project.Properties)
+			}
+
+			if !project.IsSpringBoot() || !project.IsWar() {
+				t.Fatal("project is not a Spring Boot war")
+			}
+		})
+
+		it("should not inherit from a parent that isn't at the relativePath", func() {
+			writePom("", `<project>
+  <artifactId>other</artifactId>
+  <properties><java.version>11</java.version></properties>
+</project>`)
+
+			appDir := writePom("app", `<project>
+  <parent>
+    <groupId>com.example</groupId>
+    <artifactId>platform</artifactId>
+    <version>2.0.0</version>
+  </parent>
+  <artifactId>app</artifactId>
+</project>`)
+
+			project, err := pom.Read(appDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if project.Property("java.version") != "" {
+				t.Fatalf(`unexpected inherited properties: %v`, project.Properties)
+			}
+		})
+	})
+
+	when("#IsSpringBoot", func() {
+		it("should detect the Spring Boot plugin", func() {
+			appDir := writePom("app", `<project>
+  <build>
+    <plugins>
+      <plugin>
+        <groupId>org.springframework.boot</groupId>
+        <artifactId>spring-boot-maven-plugin</artifactId>
+      </plugin>
+    </plugins>
+  </build>
+</project>`)
+
+			project, err := pom.Read(appDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !project.IsSpringBoot() {
+				t.Fatal("project is not a Spring Boot app")
+			}
+		})
+
+		it("should not detect a plugin that is only managed", func() {
+			appDir := writePom("app", `<project>
+  <build>
+    <pluginManagement>
+      <plugins>
+        <plugin>
+          <artifactId>spring-boot-maven-plugin</artifactId>
+        </plugin>
+      </plugins>
+    </pluginManagement>
+  </build>
+</project>`)
+
+			project, err := pom.Read(appDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if project.IsSpringBoot() {
+				t.Fatal("project is a Spring Boot app")
+			}
+		})
+	})
+}
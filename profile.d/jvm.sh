@@ -23,10 +23,30 @@ calculate_java_memory_opts() {
   esac
 }
 
+java_major_version() {
+  local version="$(grep '^JAVA_VERSION=' "$JAVA_HOME/release" | sed -e 's/^JAVA_VERSION="\(.*\)"/\1/')"
+  case "$version" in
+  1.*) # 1.7.0_201, 1.8.0_191
+    echo "$version" | cut -d. -f2
+    ;;
+  *) # 9.0.4, 11.0.1, 17, 21-ea
+    echo "$version" | sed -e 's/[^0-9].*$//'
+    ;;
+  esac
+}
+
 export JAVA_HOME="$(dirname $(dirname $(which java)))"
-export LD_LIBRARY_PATH="$JAVA_HOME/jre/lib/amd64/server:$LD_LIBRARY_PATH"
+java_major="$(java_major_version)"
+
+if [ -d "$JAVA_HOME/jre/lib/amd64/server" ]; then
+  export LD_LIBRARY_PATH="$JAVA_HOME/jre/lib/amd64/server:$LD_LIBRARY_PATH"
+elif [ -d "$JAVA_HOME/lib/amd64/server" ]; then # a JDK 8 JRE
+  export LD_LIBRARY_PATH="$JAVA_HOME/lib/amd64/server:$LD_LIBRARY_PATH"
+else
+  export LD_LIBRARY_PATH="$JAVA_HOME/lib/server:$LD_LIBRARY_PATH"
+fi
 
-if cat "$JAVA_HOME/release" | grep -q '^JAVA_VERSION="1[0-2]'; then
+if [ "${java_major:-0}" -ge 10 ]; then
   default_java_mem_opts="$(calculate_java_memory_opts "-XX:+UseContainerSupport")"
 else
   default_java_mem_opts="$(calculate_java_memory_opts | sed 's/^ //')"
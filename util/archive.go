@@ -0,0 +1,216 @@
+package util
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// NewHttpClient is a client that honours the proxy settings of the environment
+// and also reads file:// URLs, for installs from a local mirror.
+func NewHttpClient() *http.Client {
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	transport.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
+	return &http.Client{Transport: transport}
+}
+
+// ExtractTarGz extracts a gzipped tarball into dest. relativePath maps each
+// path in the archive to its path under dest, or reports false to skip it.
+// Entries and links that would escape dest are rejected.
+func ExtractTarGz(r io.Reader, dest string, relativePath func(name string) (string, bool)) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		name, ok := relativePath(hdr.Name)
+		if !ok {
+			continue
+		}
+
+		target, err := SecurePath(dest, name)
+		if err != nil {
+			return err
+		}
+
+		mode := hdr.FileInfo().Mode().Perm()
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := WriteFile(tr, target, mode); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			linkTarget := hdr.Linkname
+			if !filepath.IsAbs(linkTarget) {
+				linkTarget = filepath.Join(filepath.Dir(name), linkTarget)
+			}
+			if _, err := SecurePath(dest, linkTarget); err != nil || filepath.IsAbs(hdr.Linkname) {
+				return errors.New(fmt.Sprintf("symlink %s points outside of the archive: %s", hdr.Name, hdr.Linkname))
+			}
+			if err := ReplaceWithSymlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			linkName, ok := relativePath(hdr.Linkname)
+			if !ok {
+				return errors.New(fmt.Sprintf("hard link %s points outside of the extracted files: %s", hdr.Name, hdr.Linkname))
+			}
+			source, err := SecurePath(dest, linkName)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			os.Remove(target)
+			if err := os.Link(source, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ExtractZip extracts the zip file at path into dest, mapping each path in the
+// archive like ExtractTarGz.
+func ExtractZip(path, dest string, relativePath func(name string) (string, bool)) error {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		name, ok := relativePath(file.Name)
+		if !ok {
+			continue
+		}
+
+		target, err := SecurePath(dest, name)
+		if err != nil {
+			return err
+		}
+
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, file.Mode().Perm()|0700); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := extractZipFile(file, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractZipFile(file *zip.File, target string) error {
+	r, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	mode := file.Mode().Perm()
+	if mode == 0 {
+		mode = 0644
+	}
+	return WriteFile(r, target, mode)
+}
+
+// StripComponents maps archive paths to extracted paths like tar's
+// --strip-components option.
+func StripComponents(n int) func(name string) (string, bool) {
+	return func(name string) (string, bool) {
+		parts := strings.Split(strings.TrimPrefix(filepath.ToSlash(filepath.Clean(name)), "./"), "/")
+		if len(parts) <= n {
+			return "", false
+		}
+		return filepath.Join(parts[n:]...), true
+	}
+}
+
+func WriteFile(r io.Reader, path string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, r); err != nil {
+		return err
+	}
+	return out.Chmod(mode)
+}
+
+func ReplaceWithSymlink(oldname, newname string) error {
+	if err := os.MkdirAll(filepath.Dir(newname), 0755); err != nil {
+		return err
+	}
+	if _, err := os.Lstat(newname); err == nil {
+		if err := os.Remove(newname); err != nil {
+			return err
+		}
+	}
+	return os.Symlink(oldname, newname)
+}
+
+// SecurePath joins name to root, failing when the result is outside of root.
+func SecurePath(root, name string) (string, error) {
+	path := filepath.Join(root, name)
+	if path != filepath.Clean(root) && !strings.HasPrefix(path, filepath.Clean(root)+string(os.PathSeparator)) {
+		return "", errors.New(fmt.Sprintf("illegal path in archive: %s", name))
+	}
+	return path, nil
+}
+
+// ResetDir empties dir, creating it when it does not exist.
+func ResetDir(dir string) error {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return os.MkdirAll(dir, 0755)
+	} else if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		if err := os.RemoveAll(filepath.Join(dir, f.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpack/libbuildpack/layers"
+)
+
+// BomEntry describes a dependency the buildpack installed in the image's bill
+// of materials.
+type BomEntry struct {
+	Name     string      `toml:"name"`
+	Version  string      `toml:"version"`
+	Metadata BomMetadata `toml:"metadata"`
+}
+
+type BomMetadata struct {
+	Vendor      string    `toml:"vendor,omitempty"`
+	Url         string    `toml:"url"`
+	Sha256      string    `toml:"sha256"`
+	Stack       string    `toml:"stack,omitempty"`
+	InstalledAt time.Time `toml:"installed_at"`
+	// Layer is the layer the dependency is installed in
+	Layer string `toml:"layer"`
+	// Launch is whether the dependency is in the launch image, rather than
+	// only used to build it
+	Launch bool `toml:"launch"`
+}
+
+// LaunchMetadata is the launch.toml written for the lifecycle, which
+// libbuildpack's layers.Metadata can't write a bill of materials to.
+type LaunchMetadata struct {
+	Processes layers.Processes `toml:"processes"`
+	Bom       []BomEntry       `toml:"bom,omitempty"`
+}
+
+// WriteLaunchMetadata writes launch.toml to the layers directory.
+func WriteLaunchMetadata(layersDir layers.Layers, metadata LaunchMetadata) error {
+	if err := os.MkdirAll(layersDir.Root, 0755); err != nil {
+		return err
+	}
+
+	out, err := os.Create(filepath.Join(layersDir.Root, "launch.toml"))
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	return toml.NewEncoder(out).Encode(metadata)
+}
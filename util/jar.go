@@ -11,42 +11,79 @@ import (
 )
 
 func FindExecutableJar(appDir string) (layers.Processes, error) {
-	if jars, err := filepath.Glob(filepath.Join(appDir, "target", "*.jar")); err == nil {
+	return findExecutableArchiveProcess(appDir, "*.jar")
+}
+
+// FindExecutableWar finds a war in target that has a Main-Class, such as the
+// executable wars built for Spring Boot apps.
+func FindExecutableWar(appDir string) (layers.Processes, error) {
+	return findExecutableArchiveProcess(appDir, "*.war")
+}
+
+func findExecutableArchiveProcess(appDir, pattern string) (layers.Processes, error) {
+	jar, manifest, err := findExecutableArchive(appDir, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	command := "java"
+	if strings.Contains(manifest, "Start-Class") {
+		command = fmt.Sprintf("%s -Dserver.port=$PORT", command)
+	}
+	command = fmt.Sprintf("%s -jar target/%s", command, filepath.Base(jar))
+
+	webProcess := layers.Process{
+		Type:    "web",
+		Command: command,
+	}
+
+	return layers.Processes{webProcess}, nil
+}
+
+// FindExecutableJarPath returns the path of the jar in target that has a Main-Class.
+func FindExecutableJarPath(appDir string) (string, error) {
+	jar, _, err := findExecutableArchive(appDir, "*.jar")
+	return jar, err
+}
+
+func findExecutableArchive(appDir, pattern string) (string, string, error) {
+	if jars, err := filepath.Glob(filepath.Join(appDir, "target", pattern)); err == nil {
 		for _, jar := range jars {
 			// if the Jar has a Main class
-			reader, err := zip.OpenReader(jar)
+			manifest, err := readJarManifest(jar)
+			if err != nil {
+				return "", "", err
+			}
+
+			if strings.Contains(manifest, "Main-Class") {
+				return jar, manifest, nil
+			}
+		}
+	}
+	return "", "", errors.New("could not file a Jar file")
+}
+
+func readJarManifest(jar string) (string, error) {
+	reader, err := zip.OpenReader(jar)
+	if err != nil {
+		return "", errors.New("unable to open Jar file")
+	}
+	defer reader.Close()
+
+	for _, file := range reader.File {
+		if file.Name == "META-INF/MANIFEST.MF" {
+			fileReader, err := file.Open()
+			if err != nil {
+				return "", nil
+			}
+			defer fileReader.Close()
+
+			bytes, err := ioutil.ReadAll(fileReader)
 			if err != nil {
-				return nil, errors.New("unable to open Jar file")
-			} else {
-				for _, file := range reader.File {
-					if file.Name == "META-INF/MANIFEST.MF" {
-						fileReader, err := file.Open()
-						if err == nil {
-							bytes, err := ioutil.ReadAll(fileReader)
-							if err != nil {
-								return nil, errors.New("unable to read Jar file")
-							} else {
-								if strings.Contains(string(bytes), "Main-Class") {
-									command := "java"
-									if strings.Contains(string(bytes), "Start-Class") {
-										command = fmt.Sprintf("%s -Dserver.port=$PORT", command)
-									}
-									command = fmt.Sprintf("%s -jar target/%s", command, filepath.Base(jar))
-
-									webProcess := layers.Process{
-										Type:    "web",
-										Command: command,
-									}
-
-									return layers.Processes{webProcess}, nil
-								}
-							}
-						}
-						defer fileReader.Close()
-					}
-				}
+				return "", errors.New("unable to read Jar file")
 			}
+			return string(bytes), nil
 		}
 	}
-	return nil, errors.New("could not file a Jar file")
+	return "", nil
 }
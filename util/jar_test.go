@@ -1,6 +1,8 @@
 package util_test
 
 import (
+	"archive/zip"
+	"io/ioutil"
 	"os"
 	"testing"
 
@@ -37,6 +39,54 @@ func testJar(t *testing.T, when spec.G, it spec.S) {
 			}
 		})
 	})
+
+	when("#FindExecutableWar", func() {
+		it("should find an executable war", func() {
+			appDir, err := ioutil.TempDir("", "app")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(appDir)
+
+			os.MkdirAll(filepath.Join(appDir, "target"), 0755)
+			war, _ := os.Create(filepath.Join(appDir, "target", "demo-0.0.1.war"))
+			zw := zip.NewWriter(war)
+			w, _ := zw.Create("META-INF/MANIFEST.MF")
+			w.Write([]byte("Main-Class: org.springframework.boot.loader.WarLauncher\nStart-Class: com.example.DemoApplication\n"))
+			zw.Close()
+			war.Close()
+
+			processes, err := util.FindExecutableWar(appDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := "java -Dserver.port=$PORT -jar target/demo-0.0.1.war"
+			if len(processes) != 1 || processes[0].Command != expected {
+				t.Fatalf(`Did not create correct command: got %+v, want %s`, processes, expected)
+			}
+		})
+	})
+
+	when("#FindExecutableJarPath", func() {
+		it("should find the path of an executable jar", func() {
+			jar, err := util.FindExecutableJarPath(fixture("app_with_exec_jar"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := filepath.Join(fixture("app_with_exec_jar"), "target", "my-app-1.0-SNAPSHOT-jar-with-dependencies.jar")
+			if jar != expected {
+				t.Fatalf(`Did not find executable JAR: got %s, want %s`, jar, expected)
+			}
+		})
+
+		it("should fail without an executable jar", func() {
+			if _, err := util.FindExecutableJarPath(fixture("app_with_pom")); err == nil {
+				t.Fatal("unexpected success")
+			}
+		})
+	})
 }
 
 func fixture(name string) string {